@@ -0,0 +1,491 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// action is a named operation bound to a key, with an optional argument
+// given in parentheses, e.g. execute(less {}).
+type action struct {
+	name string
+	arg  string
+}
+
+// actionFunc performs an action. It returns done when the action ends the
+// session, along with the selection to output.
+type actionFunc func(a *App, arg string) (done bool, result []int)
+
+// hscrollStep is the number of columns scroll-left and scroll-right move.
+const hscrollStep = 8
+
+var actionTable = map[string]actionFunc{
+	"up": func(a *App, _ string) (bool, []int) {
+		a.moveCursorUp()
+		return false, nil
+	},
+	"down": func(a *App, _ string) (bool, []int) {
+		a.moveCursorDown()
+		return false, nil
+	},
+	"page-up": func(a *App, _ string) (bool, []int) {
+		a.moveCursorPage(-1)
+		return false, nil
+	},
+	"page-down": func(a *App, _ string) (bool, []int) {
+		a.moveCursorPage(1)
+		return false, nil
+	},
+	"first": func(a *App, _ string) (bool, []int) {
+		a.moveCursorTo(false)
+		return false, nil
+	},
+	"last": func(a *App, _ string) (bool, []int) {
+		a.moveCursorTo(true)
+		return false, nil
+	},
+	"scroll-left": func(a *App, _ string) (bool, []int) {
+		if a.hscroll == 0 && (a.collapseNode() || a.setGroupCollapsed(true) || a.ascend()) {
+			return false, nil
+		}
+		a.scrollHorizontally(-hscrollStep)
+		return false, nil
+	},
+	"scroll-right": func(a *App, _ string) (bool, []int) {
+		if a.expandNode() || a.setGroupCollapsed(false) {
+			return false, nil
+		}
+		a.scrollHorizontally(hscrollStep)
+		return false, nil
+	},
+	"toggle": func(a *App, _ string) (bool, []int) {
+		a.toggleSelection()
+		return false, nil
+	},
+	"select-all": func(a *App, _ string) (bool, []int) {
+		a.selectVisible(true, false)
+		return false, nil
+	},
+	"deselect-all": func(a *App, _ string) (bool, []int) {
+		a.selectVisible(false, false)
+		return false, nil
+	},
+	"toggle-all": func(a *App, _ string) (bool, []int) {
+		a.selectVisible(false, true)
+		return false, nil
+	},
+	"toggle-truncate": func(a *App, _ string) (bool, []int) {
+		a.toggleTruncate()
+		return false, nil
+	},
+	"backward-delete-char": func(a *App, _ string) (bool, []int) {
+		a.handleBackspace()
+		return false, nil
+	},
+	"accept": func(a *App, _ string) (bool, []int) {
+		// Inside an item, Enter descends until a leaf value is picked
+		if len(a.drill) > 0 && len(a.selected) == 0 && a.descend() {
+			return false, nil
+		}
+		// On a collapsed group, Enter shows its items
+		if len(a.selected) == 0 && a.cursor < len(a.filtered) && a.groupCollapsed(a.cursor) {
+			a.setGroupCollapsed(false)
+			return false, nil
+		}
+		if a.loop {
+			a.deliverAndContinue(a.getSelection())
+			return false, nil
+		}
+		return true, a.getSelection()
+	},
+	"abort": func(a *App, _ string) (bool, []int) {
+		return true, nil
+	},
+	"sort-column": func(a *App, _ string) (bool, []int) {
+		a.cycleSortColumn(1)
+		return false, nil
+	},
+	"sort-column-back": func(a *App, _ string) (bool, []int) {
+		a.cycleSortColumn(-1)
+		return false, nil
+	},
+	"sort-rows": func(a *App, _ string) (bool, []int) {
+		a.cycleSortRows()
+		return false, nil
+	},
+	"sort-direction": func(a *App, _ string) (bool, []int) {
+		a.toggleSortDirection()
+		return false, nil
+	},
+	"toggle-preview": func(a *App, _ string) (bool, []int) {
+		a.showPreview = !a.showPreview
+		return false, nil
+	},
+	"push-filter": func(a *App, _ string) (bool, []int) {
+		a.pushFilter()
+		return false, nil
+	},
+	"toggle-regex": func(a *App, _ string) (bool, []int) {
+		a.toggleRegex()
+		return false, nil
+	},
+	"toggle-search-all": func(a *App, _ string) (bool, []int) {
+		a.toggleSearchAll()
+		return false, nil
+	},
+	"toggle-case": func(a *App, _ string) (bool, []int) {
+		a.toggleCase()
+		return false, nil
+	},
+	"descend": func(a *App, _ string) (bool, []int) {
+		if a.treeMode {
+			a.toggleNode()
+			return false, nil
+		}
+		if a.groupBy != "" {
+			a.toggleGroup()
+			return false, nil
+		}
+		a.descend()
+		return false, nil
+	},
+	"ascend": func(a *App, _ string) (bool, []int) {
+		a.ascend()
+		return false, nil
+	},
+	"next-group": func(a *App, _ string) (bool, []int) {
+		a.jumpGroup(1)
+		return false, nil
+	},
+	"previous-group": func(a *App, _ string) (bool, []int) {
+		a.jumpGroup(-1)
+		return false, nil
+	},
+	"toggle-group": func(a *App, _ string) (bool, []int) {
+		a.toggleGroup()
+		return false, nil
+	},
+	"toggle-facets": func(a *App, _ string) (bool, []int) {
+		a.toggleFacets()
+		return false, nil
+	},
+	"focus-facets": func(a *App, _ string) (bool, []int) {
+		a.focusFacets()
+		return false, nil
+	},
+	"toggle-jq": func(a *App, _ string) (bool, []int) {
+		a.toggleJQMode()
+		return false, nil
+	},
+	"normal-mode": func(a *App, _ string) (bool, []int) {
+		a.normalMode = true
+		return false, nil
+	},
+	"insert-mode": func(a *App, _ string) (bool, []int) {
+		a.normalMode = false
+		return false, nil
+	},
+	"reload": func(a *App, _ string) (bool, []int) {
+		a.reload()
+		return false, nil
+	},
+	"pop-filter": func(a *App, _ string) (bool, []int) {
+		a.popFilter()
+		return false, nil
+	},
+	"value-popup": func(a *App, _ string) (bool, []int) {
+		a.openValuePopup()
+		return false, nil
+	},
+	"open-link": func(a *App, _ string) (bool, []int) {
+		a.openLink()
+		return false, nil
+	},
+	"ignore": func(a *App, _ string) (bool, []int) {
+		return false, nil
+	},
+	"execute": func(a *App, arg string) (bool, []int) {
+		a.execute(arg, false)
+		return false, nil
+	},
+	"execute-silent": func(a *App, arg string) (bool, []int) {
+		a.execute(arg, true)
+		return false, nil
+	},
+	"become": func(a *App, arg string) (bool, []int) {
+		a.become(arg)
+		return false, nil
+	},
+}
+
+// defaultBindings are the key bindings in effect before --bind is applied.
+func defaultBindings() map[string]action {
+	return map[string]action{
+		"up":         {name: "up"},
+		"down":       {name: "down"},
+		"pgup":       {name: "page-up"},
+		"pgdn":       {name: "page-down"},
+		"home":       {name: "first"},
+		"end":        {name: "last"},
+		"left":       {name: "scroll-left"},
+		"right":      {name: "scroll-right"},
+		"alt-h":      {name: "scroll-left"},
+		"alt-l":      {name: "scroll-right"},
+		"ctrl-space": {name: "toggle"},
+		"enter":      {name: "accept"},
+		"esc":        {name: "abort"},
+		"ctrl-c":     {name: "abort"},
+		"bspace":     {name: "backward-delete-char"},
+		"ctrl-h":     {name: "backward-delete-char"},
+		"ctrl-o":     {name: "open-link"},
+		"f3":         {name: "sort-column"},
+		"f4":         {name: "sort-direction"},
+		"alt-<":      {name: "sort-column-back"},
+		"alt->":      {name: "sort-column"},
+		"ctrl-/":     {name: "toggle-preview"},
+		"f2":         {name: "value-popup"},
+		"ctrl-f":     {name: "push-filter"},
+		"ctrl-n":     {name: "down"},
+		"ctrl-p":     {name: "up"},
+		"alt-r":      {name: "toggle-regex"},
+		"alt-a":      {name: "toggle-search-all"},
+		"alt-c":      {name: "toggle-case"},
+		"alt-j":      {name: "toggle-jq"},
+		"alt-down":   {name: "next-group"},
+		"alt-up":     {name: "previous-group"},
+		"f5":         {name: "toggle-facets"},
+		"alt-f":      {name: "focus-facets"},
+		"tab":        {name: "descend"},
+		"ctrl-s":     {name: "sort-rows"},
+		"ctrl-r":     {name: "reload"},
+
+		// Mouse events
+		"scroll-up":    {name: "up"},
+		"scroll-down":  {name: "down"},
+		"double-click": {name: "accept"},
+	}
+}
+
+// normalModeBindings are the keys of the vi normal mode, in effect with
+// --vi after Esc. Other keys fall through to the regular bindings, except
+// that typing does not edit the filter.
+func normalModeBindings() map[string]action {
+	return map[string]action{
+		"j":      {name: "down"},
+		"k":      {name: "up"},
+		"h":      {name: "scroll-left"},
+		"l":      {name: "scroll-right"},
+		"g":      {name: "first"},
+		"G":      {name: "last"},
+		"ctrl-d": {name: "page-down"},
+		"ctrl-u": {name: "page-up"},
+		"x":      {name: "toggle"},
+		"i":      {name: "insert-mode"},
+		"a":      {name: "insert-mode"},
+		"/":      {name: "insert-mode"},
+		"q":      {name: "abort"},
+		"esc":    {name: "abort"},
+	}
+}
+
+// parseBindings parses a --bind value: a comma separated list of key:action
+// pairs. Commas inside an action argument are kept.
+func parseBindings(spec string, bindings map[string]action) error {
+	for _, binding := range splitBindings(spec) {
+		key, actionSpec, ok := strings.Cut(binding, ":")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid binding: %s", binding)
+		}
+		if !isKeyName(key) {
+			return fmt.Errorf("unknown key in binding: %s", key)
+		}
+
+		act, err := parseAction(actionSpec)
+		if err != nil {
+			return err
+		}
+		bindings[key] = act
+	}
+	return nil
+}
+
+func splitBindings(spec string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(spec); i++ {
+		switch spec[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			// a comma directly after the colon is the key itself, e.g. ",:up"
+			if depth == 0 && i > start {
+				parts = append(parts, spec[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, spec[start:])
+}
+
+func parseAction(spec string) (action, error) {
+	name, arg := spec, ""
+	if open := strings.IndexByte(spec, '('); open >= 0 {
+		if !strings.HasSuffix(spec, ")") {
+			return action{}, fmt.Errorf("unterminated action argument: %s", spec)
+		}
+		name, arg = spec[:open], spec[open+1:len(spec)-1]
+	}
+
+	if _, ok := actionTable[name]; !ok {
+		return action{}, fmt.Errorf("unknown action: %s", name)
+	}
+	return action{name: name, arg: arg}, nil
+}
+
+func (a *App) runAction(act action) (done bool, result []int) {
+	return actionTable[act.name](a, act.arg)
+}
+
+var placeholderPattern = regexp.MustCompile(`\{[^{}]*\}`)
+
+// expandTemplate substitutes placeholders in a command template with values
+// from the object at idx: {} is its output value (the --format template, the
+// -o attributes or the whole object as JSON) and {attr} is the value of attr.
+// Values are quoted for the shell.
+func (a *App) expandTemplate(tmpl string, idx int) string {
+	obj := a.object(idx)
+	return placeholderPattern.ReplaceAllStringFunc(tmpl, func(placeholder string) string {
+		attr := placeholder[1 : len(placeholder)-1]
+		if attr == "" && a.format != nil {
+			formatted, _ := a.executeFormat(obj)
+			return shellQuote(formatted)
+		}
+		if attr == "" && len(a.outputAttrs) > 1 {
+			values := make([]string, len(a.outputAttrs))
+			for i, attr := range a.outputAttrs {
+				if val, ok := a.attrValue(obj, attr); ok {
+					values[i], _ = formatOutputValue(val)
+				}
+			}
+			return shellQuote(strings.Join(values, a.delimiter))
+		}
+		if attr == "" {
+			attr = a.outputAttr
+		}
+
+		var value string
+		if _, ok := elementValue(obj); ok && attr == "" {
+			value = elementText(obj)
+		} else if attr == "" {
+			jsonBytes, _ := objectJSON(obj, a.rawObject(idx))
+			value = string(jsonBytes)
+		} else if val, ok := a.attrValue(obj, attr); ok {
+			value, _ = formatOutputValue(val)
+		}
+		return shellQuote(value)
+	})
+}
+
+// execute runs a command template against the current item. Interactive
+// commands get the terminal while the picker is suspended; silent commands
+// run in the background of the UI with their output discarded, and a
+// failure to start them or a non-zero exit is shown next to the filter.
+func (a *App) execute(tmpl string, silent bool) {
+	if len(a.filtered) == 0 || a.cursor >= len(a.filtered) {
+		return
+	}
+
+	cmd := shellCommand(a.expandTemplate(tmpl, a.filtered[a.cursor]))
+	if silent {
+		if err := cmd.Start(); err != nil {
+			a.message = fmt.Sprintf("execute-silent failed: %v", err)
+			return
+		}
+		go func() {
+			if err := cmd.Wait(); err != nil {
+				// Nobody reads the failure once the picker has exited
+				select {
+				case a.jobs <- err:
+				case <-a.quit:
+				}
+			}
+		}()
+		return
+	}
+
+	a.suspend()
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = a.tty, a.ttyOut, a.ttyOut
+	_ = cmd.Run()
+	a.resume()
+}
+
+// execSelection runs the --exec command template once for each selected
+// item, in order, with the terminal as its standard input. It returns the
+// error of the last command that failed.
+func (a *App) execSelection(indices []int) error {
+	var lastErr error
+	for _, idx := range indices {
+		cmd := shellCommand(a.expandTemplate(a.execCmd, idx))
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if a.tty != nil {
+			cmd.Stdin = a.tty
+		}
+		if err := cmd.Run(); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// become replaces the qjp process with a command template run against the
+// current item. When the input was piped, the command gets the terminal as
+// its standard input. The control socket and the recording are closed once
+// the command starts; if it cannot be started, they are opened again, and
+// the picker resumes and shows the error.
+func (a *App) become(tmpl string) {
+	if len(a.filtered) == 0 || a.cursor >= len(a.filtered) {
+		return
+	}
+
+	command := a.expandTemplate(tmpl, a.filtered[a.cursor])
+
+	a.suspend()
+	closed := false
+	err := execShell(command, a.tty, func() {
+		a.closeSession()
+		closed = true
+	})
+	if closed {
+		if reopenErr := a.reopenSession(); reopenErr != nil {
+			err = fmt.Errorf("%w; %v", err, reopenErr)
+		}
+	}
+	a.resume()
+	a.message = fmt.Sprintf("become failed: %v", err)
+}
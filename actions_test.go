@@ -0,0 +1,100 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build !windows
+
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecuteSilentReportsFailure(t *testing.T) {
+	app := newApp([]map[string]interface{}{{"name": "a"}}, nil, "", nil, false, false, "")
+	start := time.Now()
+	app.execute("sleep 1; exit 3", true)
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("execute-silent waited for the command")
+	}
+	select {
+	case err := <-app.jobs:
+		if !strings.Contains(err.Error(), "exit status 3") {
+			t.Errorf("got %v, want exit status 3", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the failure of the command wasn't reported")
+	}
+}
+
+func TestExecuteSilentAfterExit(t *testing.T) {
+	app := newApp([]map[string]interface{}{{"name": "a"}}, nil, "", nil, false, false, "")
+	before := runtime.NumGoroutine()
+	app.execute("exit 3", true)
+	close(app.quit)
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatal("the goroutine of the failed command still waits to report it")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReopenSession(t *testing.T) {
+	dir := t.TempDir()
+	app := newApp([]map[string]interface{}{{"name": "a"}}, nil, "", nil, false, false, "")
+	recording := filepath.Join(dir, "session")
+	var err error
+	if app.recorder, err = startRecording(recording, sessionHeader{}); err != nil {
+		t.Fatal(err)
+	}
+	app.controlPath = filepath.Join(dir, "control")
+	if app.control, err = listenControl(app.controlPath); err != nil {
+		t.Fatal(err)
+	}
+	app.commands = make(chan controlCommand)
+
+	app.closeSession()
+	app.closeSession()
+	if _, err := os.Stat(app.controlPath); err == nil {
+		t.Error("closeSession left the control socket")
+	}
+	if err := app.reopenSession(); err != nil {
+		t.Fatal(err)
+	}
+	defer app.closeSession()
+
+	app.recorder.recordKeys([]byte("x"))
+	replay, err := loadSession(recording)
+	if err != nil || len(replay.events) != 1 {
+		t.Errorf("recording after reopenSession has %v events, %v; want 1", replay, err)
+	}
+	conn, err := net.Dial("unix", app.controlPath)
+	if err != nil {
+		t.Fatalf("control socket after reopenSession: %v", err)
+	}
+	conn.Close()
+}
@@ -0,0 +1,291 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"strings"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/bidi"
+)
+
+// visualOrder reorders a single line of text from logical to visual order,
+// for terminals that do not apply the Unicode bidirectional algorithm
+// themselves. It implements the implicit part of the algorithm (UAX #9):
+// explicit embeddings, overrides and isolates are ignored, which is fine for
+// the short values qjp displays. Strings without right-to-left characters
+// are returned unchanged.
+func visualOrder(s string) string {
+	runes := []rune(s)
+	types := make([]bidi.Class, len(runes))
+	hasRTL := false
+	for i, r := range runes {
+		props, _ := bidi.LookupRune(r)
+		class := props.Class()
+		switch {
+		case class == bidi.R || class == bidi.AL || class == bidi.AN:
+			hasRTL = true
+		case class > bidi.AL:
+			// Explicit formatting characters.
+			class = bidi.BN
+		}
+		types[i] = class
+	}
+	if !hasRTL {
+		return s
+	}
+
+	paraLevel := paragraphLevel(types)
+	resolveWeakTypes(types, paraLevel)
+	resolveNeutralTypes(types, paraLevel)
+	levels := resolveLevels(types, paraLevel)
+	return reorderClusters(s, levels, paraLevel)
+}
+
+// paragraphLevel returns 1 when the first strong character is right-to-left
+// and 0 otherwise (rules P2 and P3).
+func paragraphLevel(types []bidi.Class) int {
+	for _, class := range types {
+		switch class {
+		case bidi.L:
+			return 0
+		case bidi.R, bidi.AL:
+			return 1
+		}
+	}
+	return 0
+}
+
+// directionOf returns the strong type of an embedding level.
+func directionOf(level int) bidi.Class {
+	if level%2 == 1 {
+		return bidi.R
+	}
+	return bidi.L
+}
+
+// resolveWeakTypes applies rules W1 to W7.
+func resolveWeakTypes(types []bidi.Class, paraLevel int) {
+	sos := directionOf(paraLevel)
+
+	// W1: marks take the type of the character they follow.
+	prev := sos
+	for i, class := range types {
+		if class == bidi.NSM || class == bidi.BN {
+			types[i] = prev
+		} else {
+			prev = class
+		}
+	}
+
+	// W2 and W3: numbers after Arabic letters are Arabic numbers, and
+	// Arabic letters are right-to-left.
+	strong := sos
+	for i, class := range types {
+		switch class {
+		case bidi.L, bidi.R, bidi.AL:
+			strong = class
+		case bidi.EN:
+			if strong == bidi.AL {
+				types[i] = bidi.AN
+			}
+		}
+	}
+	for i, class := range types {
+		if class == bidi.AL {
+			types[i] = bidi.R
+		}
+	}
+
+	// W4: a single separator between two numbers of the same kind joins
+	// them.
+	for i := 1; i < len(types)-1; i++ {
+		before, after := types[i-1], types[i+1]
+		switch {
+		case types[i] == bidi.ES && before == bidi.EN && after == bidi.EN:
+			types[i] = bidi.EN
+		case types[i] == bidi.CS && before == after && (before == bidi.EN || before == bidi.AN):
+			types[i] = before
+		}
+	}
+
+	// W5: terminators next to European numbers are part of them.
+	for i := 0; i < len(types); {
+		if types[i] != bidi.ET {
+			i++
+			continue
+		}
+		end := i
+		for end < len(types) && types[end] == bidi.ET {
+			end++
+		}
+		if (i > 0 && types[i-1] == bidi.EN) || (end < len(types) && types[end] == bidi.EN) {
+			for j := i; j < end; j++ {
+				types[j] = bidi.EN
+			}
+		}
+		i = end
+	}
+
+	// W6 and W7: remaining separators and terminators are neutral, and
+	// European numbers in left-to-right context are left-to-right.
+	strong = sos
+	for i, class := range types {
+		switch class {
+		case bidi.ES, bidi.ET, bidi.CS:
+			types[i] = bidi.ON
+		case bidi.L, bidi.R:
+			strong = class
+		case bidi.EN:
+			if strong == bidi.L {
+				types[i] = bidi.L
+			}
+		}
+	}
+}
+
+// isNeutral reports whether class is neutral after weak type resolution.
+func isNeutral(class bidi.Class) bool {
+	switch class {
+	case bidi.B, bidi.S, bidi.WS, bidi.ON:
+		return true
+	}
+	return false
+}
+
+// resolveNeutralTypes applies rules N1 and N2: neutrals between characters
+// of the same direction take that direction, other neutrals take the
+// paragraph direction.
+func resolveNeutralTypes(types []bidi.Class, paraLevel int) {
+	embedding := directionOf(paraLevel)
+	strongType := func(class bidi.Class) bidi.Class {
+		if class == bidi.EN || class == bidi.AN {
+			return bidi.R
+		}
+		return class
+	}
+
+	for i := 0; i < len(types); {
+		if !isNeutral(types[i]) {
+			i++
+			continue
+		}
+		end := i
+		for end < len(types) && isNeutral(types[end]) {
+			end++
+		}
+
+		before, after := embedding, embedding
+		if i > 0 {
+			before = strongType(types[i-1])
+		}
+		if end < len(types) {
+			after = strongType(types[end])
+		}
+		resolved := embedding
+		if before == after {
+			resolved = before
+		}
+		for j := i; j < end; j++ {
+			types[j] = resolved
+		}
+		i = end
+	}
+}
+
+// resolveLevels applies rules I1 and I2, giving the embedding level of each
+// character.
+func resolveLevels(types []bidi.Class, paraLevel int) []int {
+	levels := make([]int, len(types))
+	for i, class := range types {
+		level := paraLevel
+		switch {
+		case level%2 == 0 && class == bidi.R:
+			level++
+		case level%2 == 0 && (class == bidi.AN || class == bidi.EN):
+			level += 2
+		case level%2 == 1 && class != bidi.R:
+			level++
+		}
+		levels[i] = level
+	}
+	return levels
+}
+
+// reorderClusters applies rules L1 (for trailing whitespace), L2 and L4 to
+// the grapheme clusters of s, given the level of each of its runes. Clusters
+// are kept whole so that combining marks stay on their base character.
+func reorderClusters(s string, levels []int, paraLevel int) string {
+	var clusters []string
+	var clusterLevels []int
+	pos := 0
+	state := -1
+	rest := s
+	for rest != "" {
+		var cluster string
+		cluster, rest, _, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		clusters = append(clusters, cluster)
+		clusterLevels = append(clusterLevels, levels[pos])
+		pos += len([]rune(cluster))
+	}
+
+	// L1: trailing whitespace goes back to the paragraph level, so that it
+	// ends up at the end of the line in the paragraph direction.
+	for i := len(clusters) - 1; i >= 0 && strings.TrimSpace(clusters[i]) == ""; i-- {
+		clusterLevels[i] = paraLevel
+	}
+
+	// L4: mirror brackets on right-to-left levels.
+	for i, cluster := range clusters {
+		if clusterLevels[i]%2 == 1 && len([]rune(cluster)) == 1 {
+			clusters[i] = bidi.ReverseString(cluster)
+		}
+	}
+
+	// L2: from the highest level down to the lowest odd level, reverse
+	// every run of clusters at that level or higher.
+	highest, lowestOdd := 0, -1
+	for _, level := range clusterLevels {
+		highest = max(highest, level)
+		if level%2 == 1 && (lowestOdd < 0 || level < lowestOdd) {
+			lowestOdd = level
+		}
+	}
+	for level := highest; lowestOdd >= 0 && level >= lowestOdd; level-- {
+		for i := 0; i < len(clusters); {
+			if clusterLevels[i] < level {
+				i++
+				continue
+			}
+			end := i
+			for end < len(clusters) && clusterLevels[end] >= level {
+				end++
+			}
+			for a, b := i, end-1; a < b; a, b = a+1, b-1 {
+				clusters[a], clusters[b] = clusters[b], clusters[a]
+				clusterLevels[a], clusterLevels[b] = clusterLevels[b], clusterLevels[a]
+			}
+			i = end
+		}
+	}
+
+	return strings.Join(clusters, "")
+}
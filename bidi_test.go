@@ -0,0 +1,47 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import "testing"
+
+func TestVisualOrder(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"hello", "hello"},
+		{"é", "é"},
+		{"", ""},
+		{"שלום", "םולש"},
+		{"abc שלום def", "abc םולש def"},
+		{"שלום abc", "abc םולש"},
+		{"שלום 123", "123 םולש"},
+		{"مرحبا 12", "12 ابحرم"},
+		{"שלום, עולם!", "!םלוע ,םולש"},
+		{"(שלום)", "(םולש)"},
+		{"a (שלום) b", "a (םולש) b"},
+	}
+	for _, tt := range tests {
+		if got := visualOrder(tt.text); got != tt.want {
+			t.Errorf("visualOrder(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const compressInput = `[{"name": "alpha"}, {"name": "beta"}]`
+
+func gzipped(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zstded(t *testing.T, data string) []byte {
+	t.Helper()
+	w, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	return w.EncodeAll([]byte(data), nil)
+}
+
+func TestDecompress(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		want  string
+	}{
+		{"plain", []byte(compressInput), compressInput},
+		{"gzip", gzipped(t, compressInput), compressInput},
+		{"zstd", zstded(t, compressInput), compressInput},
+		{"shorter than the magic bytes", []byte("[]"), "[]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decompress(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecompressLoadsItems(t *testing.T) {
+	input, err := decompress(gzipped(t, compressInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config{}
+	objects, _, err := loadItems(input, &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 || objects[1]["name"] != "beta" {
+		t.Errorf("loaded %v", objects)
+	}
+}
+
+func TestDecompressErrors(t *testing.T) {
+	data := gzipped(t, compressInput)
+	tests := []struct {
+		name  string
+		input []byte
+		want  string
+	}{
+		{"gzip header", append(gzipMagic, 0), "error decompressing gzip input"},
+		{"truncated gzip", data[:len(data)-10], "error decompressing gzip input"},
+		{"zstd frame", append(append([]byte{}, zstdMagic...), 0xff, 0xff, 0xff), "error decompressing zstd input"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decompress(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
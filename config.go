@@ -0,0 +1,242 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// configFile is the layout of the config file. Defaults and profiles are
+// lists of command line arguments, so any option can be part of them.
+// Colors sets the colors of parts of the screen, as parseColor reads them.
+type configFile struct {
+	Defaults []string            `json:"defaults"`
+	Profiles map[string][]string `json:"profiles"`
+	Colors   map[string]string   `json:"colors"`
+
+	path string // where the file was read from, for errors
+}
+
+// configNames are the names the config file is looked up by, in order.
+var configNames = []string{"config.toml", "config.yaml", "config.json"}
+
+// colorSettings are the parts of the screen whose color the config file
+// can set, and the variables holding their escape codes.
+var colorSettings = map[string]*string{
+	"info":     &colorInfo,
+	"error":    &colorError,
+	"selected": &colorSelected,
+	"match":    &colorMatch,
+	"header":   &colorHeader,
+}
+
+// colorNames are the colors of the terminal palette, by their offset in
+// the SGR codes of foreground and background colors.
+var colorNames = map[string]int{
+	"black": 0, "red": 1, "green": 2, "yellow": 3,
+	"blue": 4, "magenta": 5, "cyan": 6, "white": 7,
+}
+
+// colorAttrs are the SGR codes of text attributes.
+var colorAttrs = map[string]string{
+	"bold": "1", "dim": "2", "italic": "3", "underline": "4", "reverse": "7",
+}
+
+// configPath returns the location of the config file: the first of
+// config.toml, config.yaml and config.json that exists in
+// $XDG_CONFIG_HOME/qjp, or ~/.config/qjp. It returns an empty path when
+// there is none.
+func configPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	for _, name := range configNames {
+		path := filepath.Join(dir, "qjp", name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// loadConfigFile reads the config file, in TOML, YAML or JSON as its
+// extension tells. A missing file is an empty config.
+func loadConfigFile() (configFile, error) {
+	var cfg configFile
+
+	path := configPath()
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	// TOML and YAML are read into JSON with the decoders of the input
+	var raws []json.RawMessage
+	switch filepath.Ext(path) {
+	case ".toml":
+		_, raws, err = parseTOML(data)
+	case ".yaml":
+		_, raws, err = parseYAML(data)
+	default:
+		raws = []json.RawMessage{data}
+	}
+	if err == nil && len(raws) > 1 {
+		err = errors.New("expected a single table of settings")
+	}
+	if err == nil && len(raws) == 1 {
+		err = json.Unmarshal(raws[0], &cfg)
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	cfg.path = path
+	return cfg, nil
+}
+
+// applyColors sets the colors of the config file.
+func (f configFile) applyColors() error {
+	for part, spec := range f.Colors {
+		color, ok := colorSettings[part]
+		if !ok {
+			return fmt.Errorf("%s: colors: unknown part %q; expected info, error, selected, match or header", f.path, part)
+		}
+		code, err := parseColor(spec)
+		if err != nil {
+			return fmt.Errorf("%s: colors: %s: %w", f.path, part, err)
+		}
+		*color = code
+	}
+	return nil
+}
+
+// parseColor returns the escape code of a color written as words, such as
+// "bold yellow" or "black on green": text attributes, a foreground color,
+// and a background color after on. "none" leaves the text as it is.
+func parseColor(spec string) (string, error) {
+	if strings.TrimSpace(spec) == "none" {
+		return "", nil
+	}
+	var codes []string
+	background := false
+	for _, word := range strings.Fields(spec) {
+		if word == "on" && !background {
+			background = true
+			continue
+		}
+		if n, ok := colorNames[word]; ok {
+			if background {
+				codes = append(codes, strconv.Itoa(40+n))
+				background = false
+			} else {
+				codes = append(codes, strconv.Itoa(30+n))
+			}
+			continue
+		}
+		if code, ok := colorAttrs[word]; ok && !background {
+			codes = append(codes, code)
+			continue
+		}
+		return "", fmt.Errorf("unknown color %q", word)
+	}
+	if background || len(codes) == 0 {
+		return "", fmt.Errorf("%q is not a color, e.g. bold yellow or black on green", spec)
+	}
+	return "\033[" + strings.Join(codes, ";") + "m", nil
+}
+
+// splitShellWords splits s into words the way a POSIX shell would, without
+// expansions: words are separated by blanks, single quotes keep everything
+// literally, and in double quotes or outside of quotes a backslash escapes
+// the next character.
+func splitShellWords(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			if quote == '"' && r != '"' && r != '\\' && r != '$' && r != '`' {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// profile returns the arguments of the named profile.
+func (f configFile) profile(name string) ([]string, error) {
+	profile, ok := f.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile: %s", name)
+	}
+	return profile, nil
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"yellow", "\033[33m"},
+		{"bold yellow", "\033[1;33m"},
+		{"black on green", "\033[30;42m"},
+		{"on blue", "\033[44m"},
+		{"  underline  ", "\033[4m"},
+		{"none", ""},
+	}
+	for _, tt := range tests {
+		got, err := parseColor(tt.spec)
+		if err != nil || got != tt.want {
+			t.Errorf("parseColor(%q) = %q, %v; want %q", tt.spec, got, err, tt.want)
+		}
+	}
+	for _, spec := range []string{"", "purple", "on", "bold on", "red on on blue", "on bold"} {
+		if got, err := parseColor(spec); err == nil {
+			t.Errorf("parseColor(%q) = %q, want an error", spec, got)
+		}
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	if err := os.MkdirAll(filepath.Join(dir, "qjp"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, config string) {
+		if err := os.WriteFile(filepath.Join(dir, "qjp", name), []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := []struct {
+		name   string
+		config string
+	}{
+		{"config.json", `{"defaults": ["--json"], "profiles": {"p": ["-m"]}}`},
+		{"config.yaml", "defaults: [--yaml]\nprofiles:\n  p: [-m]\n"},
+		{"config.toml", "defaults = [\"--toml\"]\n[profiles]\np = [\"-m\"]\n"},
+	}
+	// Each file takes over from the ones written before it
+	for _, f := range files {
+		write(f.name, f.config)
+		file, err := loadConfigFile()
+		if err != nil {
+			t.Fatalf("%s: %v", f.name, err)
+		}
+		want := "--" + strings.TrimPrefix(filepath.Ext(f.name), ".")
+		if !slices.Equal(file.Defaults, []string{want}) || !slices.Equal(file.Profiles["p"], []string{"-m"}) {
+			t.Errorf("%s: defaults %q, profiles %q", f.name, file.Defaults, file.Profiles)
+		}
+		if filepath.Base(file.path) != f.name {
+			t.Errorf("read %s instead of %s", file.path, f.name)
+		}
+	}
+
+	write("config.toml", "defaults = [")
+	if _, err := loadConfigFile(); err == nil || !strings.Contains(err.Error(), "config.toml") {
+		t.Errorf("loadConfigFile() = %v, want an error about config.toml", err)
+	}
+}
+
+func TestApplyColors(t *testing.T) {
+	saved := colorMatch
+	defer func() { colorMatch = saved }()
+
+	file := configFile{Colors: map[string]string{"match": "bold magenta"}}
+	if err := file.applyColors(); err != nil {
+		t.Fatal(err)
+	}
+	if colorMatch != "\033[1;35m" {
+		t.Errorf("colorMatch = %q, want bold magenta", colorMatch)
+	}
+
+	file = configFile{Colors: map[string]string{"prompt": "red"}}
+	if err := file.applyColors(); err == nil {
+		t.Error("applyColors accepted an unknown part")
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+// enableVirtualTerminal is a no-op outside Windows, where terminals always
+// process ANSI escape sequences.
+func enableVirtualTerminal(f *os.File) error {
+	return nil
+}
+
+// openTerminal opens the controlling terminal, which is used both to read
+// keys and to draw the list.
+func openTerminal() (in, out *os.File, err error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening /dev/tty: %w", err)
+	}
+	return tty, tty, nil
+}
+
+// notifyReload relays SIGUSR1, which asks for the input file to be reloaded.
+func notifyReload(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGUSR1)
+}
+
+// notifyResize relays SIGWINCH, which the terminal sends when it is resized.
+func notifyResize(c chan<- os.Signal, tty *os.File) {
+	signal.Notify(c, syscall.SIGWINCH)
+}
+
+// shellCommand returns a command running command with the POSIX shell.
+func shellCommand(command string) *exec.Cmd {
+	return exec.Command("sh", "-c", command)
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// execShell replaces qjp with the shell running command. When the input was
+// piped, the terminal becomes the standard input of the command. started is
+// called right before qjp is replaced; if that fails after all, the error is
+// returned and qjp goes on.
+func execShell(command string, tty *os.File, started func()) error {
+	shell, err := exec.LookPath("sh")
+	if err != nil {
+		return err
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		_ = unix.Dup2(int(tty.Fd()), int(os.Stdin.Fd()))
+	}
+	started()
+	return syscall.Exec(shell, []string{"sh", "-c", command}, os.Environ())
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/term"
+)
+
+// enableVirtualTerminal turns on ANSI escape sequence processing for the
+// console behind f. Consoles that predate Windows 10 don't support it, in
+// which case an error is returned.
+func enableVirtualTerminal(f *os.File) error {
+	handle := windows.Handle(f.Fd())
+
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		return err
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return nil
+	}
+	return windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+}
+
+// openTerminal opens the console: keys are read from its input buffer and
+// the list is drawn on its screen buffer.
+func openTerminal() (in, out *os.File, err error) {
+	in, err = os.OpenFile("CONIN$", os.O_RDWR, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening CONIN$: %w", err)
+	}
+	out, err = os.OpenFile("CONOUT$", os.O_RDWR, 0)
+	if err != nil {
+		in.Close()
+		return nil, nil, fmt.Errorf("opening CONOUT$: %w", err)
+	}
+	return in, out, nil
+}
+
+// notifyReload does nothing: Windows has no SIGUSR1, the input can be
+// reloaded through the control socket instead.
+func notifyReload(c chan<- os.Signal) {}
+
+// resizeEvent stands in for SIGWINCH, which Windows does not have.
+type resizeEvent struct{}
+
+func (resizeEvent) String() string { return "resize" }
+func (resizeEvent) Signal()        {}
+
+// resizePollInterval is how often the console size is checked for changes.
+const resizePollInterval = 250 * time.Millisecond
+
+// notifyResize polls the size of the console and sends a resizeEvent on c
+// when it changes.
+func notifyResize(c chan<- os.Signal, tty *os.File) {
+	width, height, _ := getTerminalSize(tty)
+	go func() {
+		for range time.Tick(resizePollInterval) {
+			w, h, _ := getTerminalSize(tty)
+			if w == width && h == height {
+				continue
+			}
+			width, height = w, h
+			select {
+			case c <- resizeEvent{}:
+			default:
+			}
+		}
+	}()
+}
+
+// shellCommand returns a command running command with cmd.exe.
+func shellCommand(command string) *exec.Cmd {
+	cmd := exec.Command("cmd")
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: `cmd /S /C "` + command + `"`}
+	return cmd
+}
+
+// cmdMetachars are the characters cmd.exe acts on outside double quotes.
+const cmdMetachars = "()%!^\"<>&|"
+
+// shellQuote quotes s as a single argument of a program run by cmd.exe. s is
+// quoted for the command line parser of the program, then every character
+// cmd.exe acts on is escaped with ^, so that cmd.exe passes it on unchanged:
+// no quote in s can end the quoting, no & or | can start another command,
+// and %VAR% is not expanded, the escaped name being that of no variable.
+// This only holds outside double quotes in the command, so placeholders
+// must not be quoted there. Line breaks, which end a cmd.exe command, are
+// replaced with spaces.
+func shellQuote(s string) string {
+	s = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(s)
+	var quoted strings.Builder
+	for _, r := range syscall.EscapeArg(s) {
+		if strings.ContainsRune(cmdMetachars, r) {
+			quoted.WriteByte('^')
+		}
+		quoted.WriteRune(r)
+	}
+	return quoted.String()
+}
+
+// execShell runs command in place of qjp and exits with its status, since
+// Windows cannot replace a running process. When the input was piped, the
+// console becomes the standard input of the command. started is called once
+// the command is running.
+func execShell(command string, tty *os.File, started func()) error {
+	cmd := shellCommand(command)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		cmd.Stdin = tty
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	started()
+	_ = cmd.Wait()
+	os.Exit(cmd.ProcessState.ExitCode())
+	return nil
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build windows
+
+package main
+
+import "testing"
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		value, want string
+	}{
+		{"plain", "plain"},
+		{"a b", `^"a b^"`},
+		{"a&calc", "a^&calc"},
+		{"a|b^c", "a^|b^^c"},
+		{"%PATH%", "^%PATH^%"},
+		{`say "hi" & calc`, `^"say \^"hi\^" ^& calc^"`},
+		{"two\nlines", `^"two lines^"`},
+	}
+	for _, tt := range tests {
+		if got := shellQuote(tt.value); got != tt.want {
+			t.Errorf("shellQuote(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// controlCommand is a single line received on the control socket. The UI
+// loop executes it and writes the response back to conn.
+type controlCommand struct {
+	line string
+	conn net.Conn
+}
+
+// listenControl opens the unix socket used to steer a running instance.
+// A stale socket left behind by a previous instance is removed first.
+func listenControl(path string) (net.Listener, error) {
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	return net.Listen("unix", path)
+}
+
+// serveControl accepts connections and forwards their commands, one per
+// line, to the UI loop until the listener is closed.
+func serveControl(listener net.Listener, commands chan<- controlCommand) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				commands <- controlCommand{line: scanner.Text(), conn: conn}
+			}
+		}()
+	}
+}
+
+// handleControl executes a control command. It returns done when the
+// command ends the session, along with the selection to output.
+func (a *App) handleControl(line string) (done bool, result []int, reply string) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+
+	switch name {
+	case "query":
+		a.filter = arg
+		a.updateFilter()
+	case "reload":
+		if a.load == nil {
+			return false, nil, "error: input cannot be reloaded"
+		}
+		a.reload()
+		if a.message != "" {
+			return false, nil, "error: " + a.message
+		}
+	case "pos":
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 0 || n >= len(a.filtered) {
+			return false, nil, fmt.Sprintf("error: invalid position: %s", arg)
+		}
+		a.cursor = n
+	case "up":
+		a.moveCursorUp()
+	case "down":
+		a.moveCursorDown()
+	case "toggle":
+		a.toggleSelection()
+	case "accept":
+		done, result := a.runAction(action{name: "accept"})
+		return done, result, "ok"
+	case "abort":
+		return true, nil, "ok"
+	default:
+		return false, nil, fmt.Sprintf("error: unknown command: %s", name)
+	}
+
+	return false, nil, "ok"
+}
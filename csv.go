@@ -0,0 +1,128 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// parseCSV decodes delimited text whose first record is a header row: each
+// following record becomes an object mapping the header names to its
+// fields, which are all strings. comma is ',' for CSV and '\t' for TSV,
+// which has no quoting: every line is a record and tabs separate fields.
+func parseCSV(input []byte, comma rune) ([]map[string]interface{}, []json.RawMessage, error) {
+	var read func() ([]string, error)
+	if comma == '\t' {
+		read = tsvReader(input)
+	} else {
+		reader := csv.NewReader(bytes.NewReader(input))
+		reader.Comma = comma
+		read = reader.Read
+	}
+
+	header, err := read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing header row: %w", err)
+	}
+	// The csv package checks the number of fields itself
+	if comma == '\t' {
+		read = checkFields(read, len(header))
+	}
+	names := make([]string, len(header))
+	keys := make([][]byte, len(header))
+	for i, name := range header {
+		if name == "" {
+			name = fmt.Sprintf("column%d", i+1)
+		}
+		names[i] = name
+		keys[i], _ = json.Marshal(name)
+	}
+
+	var objects []map[string]interface{}
+	var raws []json.RawMessage
+	for {
+		record, err := read()
+		if errors.Is(err, io.EOF) {
+			return objects, raws, nil
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing record: %w", err)
+		}
+
+		// Build the JSON text too, so that output keeps the column order
+		obj := make(map[string]interface{}, len(record))
+		var buf bytes.Buffer
+		buf.WriteByte('{')
+		for i, field := range record {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			value, _ := json.Marshal(field)
+			buf.Write(keys[i])
+			buf.WriteByte(':')
+			buf.Write(value)
+			obj[names[i]] = field
+		}
+		buf.WriteByte('}')
+		objects = append(objects, obj)
+		raws = append(raws, buf.Bytes())
+	}
+}
+
+// tsvReader returns a function reading the records of TSV input one line at
+// a time.
+func tsvReader(input []byte) func() ([]string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(input))
+	scanner.Buffer(nil, len(input)+1)
+	return func() ([]string, error) {
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		return strings.Split(strings.TrimSuffix(scanner.Text(), "\r"), "\t"), nil
+	}
+}
+
+// checkFields wraps read so that records whose number of fields differs
+// from the header are errors, as in the csv package.
+func checkFields(read func() ([]string, error), fields int) func() ([]string, error) {
+	line := 1
+	return func() ([]string, error) {
+		record, err := read()
+		line++
+		if err == nil && len(record) != fields {
+			return nil, fmt.Errorf("record on line %d: wrong number of fields", line)
+		}
+		return record, err
+	}
+}
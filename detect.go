@@ -0,0 +1,135 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	tomlTable = regexp.MustCompile(`^\[\[?\s*[A-Za-z_][A-Za-z0-9_.-]*\s*\]\]?\s*(#.*)?$`)
+	tomlPair  = regexp.MustCompile(`^("[^"]*"|'[^']*'|[A-Za-z0-9_-]+)(\s*\.\s*("[^"]*"|'[^']*'|[A-Za-z0-9_-]+))*\s*=`)
+	yamlPair  = regexp.MustCompile(`^("[^"]*"|'[^']*'|[^\s"'#,\[\]{}-][^:,]*):(\s|$)`)
+)
+
+var errUnknownFormat = errors.New("cannot tell the input format: expected JSON, YAML, TOML, CSV or TSV; use --yaml, --toml, --csv or --tsv to choose one")
+
+// detectFormat sets the input format of cfg when no format flag was given.
+// The extension of the file or URL decides first; otherwise the first line
+// of input that isn't blank or a comment is sniffed. JSON, which needs no
+// flag, is left as is.
+func detectFormat(cfg *config, input []byte) error {
+	if cfg.yaml || cfg.toml || cfg.csv || cfg.tsv || cfg.lineMode || cfg.ndjson {
+		return nil
+	}
+
+	switch inputExt(*cfg) {
+	case ".json", ".jsonl", ".ndjson":
+		return nil
+	case ".yaml", ".yml":
+		cfg.yaml = true
+		return nil
+	case ".toml":
+		cfg.toml = true
+		return nil
+	case ".csv":
+		cfg.csv = true
+		return nil
+	case ".tsv", ".tab":
+		cfg.tsv = true
+		return nil
+	}
+
+	line := firstLine(input)
+	switch {
+	case line == "" || isTOMLTable(line):
+		// Empty input is reported as having no objects
+		cfg.toml = line != ""
+	case line[0] == '[' || line[0] == '{':
+	case line == "---" || line == "-" || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "- "):
+		cfg.yaml = true
+	case tomlPair.MatchString(line):
+		cfg.toml = true
+	case strings.Contains(line, "\t"):
+		cfg.tsv = true
+	case yamlPair.MatchString(line):
+		cfg.yaml = true
+	case strings.Contains(line, ","):
+		cfg.csv = true
+	default:
+		return errUnknownFormat
+	}
+	return nil
+}
+
+// inputExt returns the extension of the input file or URL of cfg, in lower
+// case and without the extension of its compression, as in .json for
+// events.json.gz.
+func inputExt(cfg config) string {
+	name := cfg.filename
+	if cfg.url != "" {
+		if u, err := url.Parse(cfg.url); err == nil {
+			name = u.Path
+		}
+	}
+	name = strings.ToLower(name)
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".zst")
+	return path.Ext(name)
+}
+
+// isNDJSON tells whether the input of cfg is known to be NDJSON, from
+// --ndjson or its extension, so that a single line is one record.
+func isNDJSON(cfg config) bool {
+	ext := inputExt(cfg)
+	return cfg.ndjson || ext == ".ndjson" || ext == ".jsonl"
+}
+
+// isTOMLTable tells whether line is a TOML table header rather than a JSON
+// array holding true, false or null.
+func isTOMLTable(line string) bool {
+	if !tomlTable.MatchString(line) {
+		return false
+	}
+	switch strings.Trim(line, "[] \t") {
+	case "true", "false", "null":
+		return false
+	}
+	return true
+}
+
+// firstLine returns the first line of input that isn't blank or a comment,
+// with surrounding whitespace removed.
+func firstLine(input []byte) string {
+	for len(input) > 0 {
+		var line []byte
+		line, input, _ = bytes.Cut(input, []byte("\n"))
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 && line[0] != '#' {
+			return string(line)
+		}
+	}
+	return ""
+}
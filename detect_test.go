@@ -0,0 +1,77 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import "testing"
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   config
+		input string
+		want  string
+	}{
+		{"json array", config{}, `[{"a":1}]`, "json"},
+		{"json object", config{}, `{"a":1}`, "json"},
+		{"json after comment", config{}, "# list\n\n[1]", "json"},
+		{"json of nulls", config{}, "[null]", "json"},
+		{"empty", config{}, "", "json"},
+		{"yaml document", config{}, "---\na: 1", "yaml"},
+		{"yaml list", config{}, "- a: 1", "yaml"},
+		{"yaml mapping", config{}, "name: web", "yaml"},
+		{"toml table", config{}, "[[hosts]]\nname = \"a\"", "toml"},
+		{"toml pair", config{}, "title = \"x\"", "toml"},
+		{"toml dotted key", config{}, "a.b = 1", "toml"},
+		{"tsv", config{}, "name\tport", "tsv"},
+		{"csv", config{}, "name,port", "csv"},
+		{"unknown", config{}, "hello", "unknown"},
+		{"yaml extension", config{filename: "pods.YML"}, "name,port", "yaml"},
+		{"toml extension", config{filename: "Cargo.lock.toml"}, "", "toml"},
+		{"csv extension", config{filename: "hosts.csv.gz"}, "a: 1", "csv"},
+		{"tsv extension", config{filename: "hosts.tab"}, "", "tsv"},
+		{"json extension", config{filename: "a.jsonl"}, "name,port", "json"},
+		{"url extension", config{url: "https://example.com/a.yaml?raw=1"}, "", "yaml"},
+		{"flag", config{csv: true}, "- a: 1", "csv"},
+		{"line mode", config{lineMode: true}, "a: 1", "json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			err := detectFormat(&cfg, []byte(tt.input))
+			got := "json"
+			switch {
+			case err != nil:
+				got = "unknown"
+			case cfg.yaml:
+				got = "yaml"
+			case cfg.toml:
+				got = "toml"
+			case cfg.csv:
+				got = "csv"
+			case cfg.tsv:
+				got = "tsv"
+			}
+			if got != tt.want {
+				t.Errorf("detectFormat(%q) = %s, %v; want %s", tt.input, got, err, tt.want)
+			}
+		})
+	}
+}
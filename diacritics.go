@@ -0,0 +1,105 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// foldText returns text as queries match it: without the accents of its
+// letters unless --literal is given, so that sao matches São, and in
+// lowercase when lower is set.
+func (a *App) foldText(text string, lower bool) string {
+	if lower {
+		text = strings.ToLower(text)
+	}
+	if a.literal || isASCII(text) {
+		return text
+	}
+	return stripMarks(text)
+}
+
+// stripMarks turns the letters of text into their base letters, taking
+// the combining marks off letters such as ã or é.
+func stripMarks(text string) string {
+	stripped := strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+		return r
+	}, norm.NFD.String(text))
+	return norm.NFC.String(stripped)
+}
+
+// isASCII tells whether s holds ASCII characters only, which have no
+// accents to strip.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// accentedLetters maps the base letters of the Latin script to their
+// accented forms, as in a to àáâãäå.
+var accentedLetters = sync.OnceValue(func() map[rune][]rune {
+	letters := make(map[rune][]rune)
+	for _, r := range unicode.Latin.R16 {
+		for c := rune(r.Lo); c <= rune(r.Hi); c += rune(r.Stride) {
+			if c < utf8.RuneSelf {
+				continue
+			}
+			folded := stripMarks(string(c))
+			base, size := utf8.DecodeRuneInString(folded)
+			if size == len(folded) && base < utf8.RuneSelf {
+				letters[base] = append(letters[base], c)
+			}
+		}
+	}
+	return letters
+})
+
+// letterPattern returns a regular expression matching text where
+// foldText matches it, so that the highlighted text is the matched one:
+// each letter of text also matches its accented forms.
+func (a *App) letterPattern(text string) string {
+	if a.literal {
+		return regexp.QuoteMeta(text)
+	}
+	var pattern strings.Builder
+	for _, r := range a.foldText(text, false) {
+		accented := accentedLetters()[r]
+		if len(accented) == 0 {
+			pattern.WriteString(regexp.QuoteMeta(string(r)))
+			continue
+		}
+		pattern.WriteString("[" + string(r) + string(accented) + "]")
+	}
+	return pattern.String()
+}
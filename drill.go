@@ -0,0 +1,220 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/template"
+)
+
+// drillLevel is the state of the list one level above the nested value being
+// browsed, restored when going back up.
+type drillLevel struct {
+	objects      []map[string]interface{}
+	paths        []itemPath
+	displayAttrs []string
+	outputAttr   string
+	outputAttrs  []string
+	format       *template.Template
+	allAttrs     bool
+	filter       string
+	filterStack  []string
+	selected     map[int]int
+	sortColumn   int
+	sortBy       string
+	sortRows     bool
+	facets       *facetBar
+	groupBy      string
+	item         int    // item the cursor was on
+	node         string // jq path of the value descended into
+}
+
+// descend replaces the list with the members of the highlighted item, or of
+// the value of the highlighted member once inside an item: the keys and
+// values of an object, or the indices and elements of an array. It reports
+// false when there is nothing to descend into.
+func (a *App) descend() bool {
+	if a.cursor >= len(a.filtered) || a.jqMode {
+		return false
+	}
+	idx := a.filtered[a.cursor]
+	node, raw := a.drillNode(idx)
+	if node == "" {
+		return false
+	}
+	objects, raws, err := nodeEntries(raw)
+	if err != nil || len(objects) == 0 {
+		return false
+	}
+
+	a.drill = append(a.drill, drillLevel{
+		objects:      a.objects,
+		paths:        a.paths,
+		displayAttrs: a.displayAttrs,
+		outputAttr:   a.outputAttr,
+		outputAttrs:  a.outputAttrs,
+		format:       a.format,
+		allAttrs:     a.allAttrs,
+		filter:       a.filter,
+		filterStack:  a.filterStack,
+		selected:     a.selected,
+		sortColumn:   a.sortColumn,
+		sortBy:       a.sortBy,
+		sortRows:     a.sortRows,
+		facets:       a.facets,
+		groupBy:      a.groupBy,
+		item:         idx,
+		node:         node,
+	})
+
+	paths := make([]itemPath, len(objects))
+	for i, obj := range objects {
+		member := jqKey(fmt.Sprint(obj["key"]))
+		if raw[0] == '[' {
+			member = fmt.Sprintf("[%d]", i)
+		}
+		paths[i] = itemPath{object: node + member, raw: raws[i], value: true}
+	}
+	a.objects, a.paths = objects, paths
+	a.attrs = nil
+	a.displayAttrs = []string{"key", "value"}
+	a.outputAttr, a.outputAttrs, a.format = "value", nil, nil
+	a.allAttrs = false
+	a.filter, a.filterStack = "", nil
+	a.selected = make(map[int]int)
+	a.sortColumn, a.sortBy, a.sortRows = -1, "", false
+	a.facets, a.groupBy = nil, ""
+	a.cursor, a.hscroll = 0, 0
+	a.refreshLevel()
+	return true
+}
+
+// drillNode returns the jq path and the JSON text of the value the item at
+// idx descends into, or an empty path when it is not an object or array.
+func (a *App) drillNode(idx int) (string, json.RawMessage) {
+	path := a.paths[idx]
+	raw := a.rawObject(idx)
+	if raw == nil {
+		raw, _ = objectJSON(a.objects[idx], nil)
+	}
+	node := path.String()
+	if len(a.drill) > 0 || path.value {
+		// Members are wrapped in objects
+		var member struct {
+			Value json.RawMessage `json:"value"`
+		}
+		if err := json.Unmarshal(raw, &member); err != nil {
+			return "", nil
+		}
+		raw, node = member.Value, path.object
+	}
+	if len(raw) == 0 || (raw[0] != '{' && raw[0] != '[') {
+		return "", nil
+	}
+	return node, raw
+}
+
+// nodeEntries lists the members of the JSON object or array raw as objects
+// holding a key, or an array index, and its value.
+func nodeEntries(raw json.RawMessage) ([]map[string]interface{}, []json.RawMessage, error) {
+	if raw[0] == '{' {
+		return objectEntries(raw)
+	}
+
+	var elements []json.RawMessage
+	if err := json.Unmarshal(raw, &elements); err != nil {
+		return nil, nil, err
+	}
+	objects := make([]map[string]interface{}, len(elements))
+	raws := make([]json.RawMessage, len(elements))
+	for i, elem := range elements {
+		raws[i] = json.RawMessage(fmt.Sprintf(`{"key":%d,"value":%s}`, i, elem))
+		var err error
+		if objects[i], err = decodeObject(raws[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	return objects, raws, nil
+}
+
+// ascend goes back to the list the current one was descended from, with
+// the cursor on the item descended into. It reports false at the top.
+func (a *App) ascend() bool {
+	if len(a.drill) == 0 {
+		return false
+	}
+	level := a.drill[len(a.drill)-1]
+	a.drill = a.drill[:len(a.drill)-1]
+
+	a.objects, a.paths = level.objects, level.paths
+	a.attrs = nil
+	a.displayAttrs = level.displayAttrs
+	a.outputAttr, a.outputAttrs, a.format = level.outputAttr, level.outputAttrs, level.format
+	a.allAttrs = level.allAttrs
+	a.filter, a.filterStack = level.filter, level.filterStack
+	a.selected = level.selected
+	a.sortColumn, a.sortBy, a.sortRows = level.sortColumn, level.sortBy, level.sortRows
+	a.facets, a.groupBy = level.facets, level.groupBy
+	a.hscroll = 0
+	if a.allAttrs {
+		// Objects may have been streamed in while below
+		a.displayAttrs = getAllAttributes(a.objects)
+		for _, col := range a.columns {
+			a.displayAttrs = append(a.displayAttrs, col.name)
+		}
+	}
+	a.refreshLevel()
+	a.cursor = 0
+	for i, idx := range a.filtered {
+		if idx == level.item {
+			a.cursor = i
+			break
+		}
+	}
+	return true
+}
+
+// ascendToTop goes back to the list of input items.
+func (a *App) ascendToTop() {
+	for a.ascend() {
+	}
+}
+
+// refreshLevel recomputes the filtered items and column widths after the
+// list changed level.
+func (a *App) refreshLevel() {
+	if a.tableMode && len(a.displayAttrs) > 0 {
+		a.calculateColumnWidths()
+	}
+	a.filterErr = ""
+	a.computeBaseItems()
+	a.updateFilter()
+}
+
+// breadcrumb returns the jq path of the value being browsed, or an empty
+// string at the top.
+func (a *App) breadcrumb() string {
+	if len(a.drill) == 0 {
+		return ""
+	}
+	return a.drill[len(a.drill)-1].node
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// listedKeys returns the keys of the listed members of a drill level.
+func listedKeys(app *App) []string {
+	var keys []string
+	for _, idx := range app.filtered {
+		keys = append(keys, app.getDisplayValue(app.objects[idx]))
+	}
+	return keys
+}
+
+const drillInput = `[{"name": "a", "spec": {"ports": [80, 443], "tls": true}}, {"name": "b", "spec": {}}]`
+
+func TestDescend(t *testing.T) {
+	app := newLoadedApp(t, drillInput, "name")
+	if !app.descend() {
+		t.Fatal("descend() = false on an object")
+	}
+	if got, want := listedKeys(app), []string{`name - a`, `spec - {"ports":[80,443],"tls":true}`}; !slices.Equal(got, want) {
+		t.Errorf("listed %q, want %q", got, want)
+	}
+
+	app.cursor = 1
+	if !app.descend() {
+		t.Fatal("descend() = false on spec")
+	}
+	app.cursor = 0
+	if !app.descend() {
+		t.Fatal("descend() = false on ports")
+	}
+	if got, want := app.breadcrumb(), ".[0].spec.ports"; got != want {
+		t.Errorf("breadcrumb %q, want %q", got, want)
+	}
+	if got, want := listedKeys(app), []string{"0 - 80", "1 - 443"}; !slices.Equal(got, want) {
+		t.Errorf("listed %q, want %q", got, want)
+	}
+	if got, want := app.paths[app.filtered[1]].String(), ".[0].spec.ports[1]"; got != want {
+		t.Errorf("path %q, want %q", got, want)
+	}
+	if app.descend() {
+		t.Error("descend() = true on a number")
+	}
+}
+
+func TestDescendEmpty(t *testing.T) {
+	app := newLoadedApp(t, drillInput, "name")
+	app.cursor = 1
+	app.descend()
+	app.cursor = 1
+	if app.descend() {
+		t.Error("descend() = true on an empty object")
+	}
+	if got := app.breadcrumb(); got != ".[1]" {
+		t.Errorf("breadcrumb %q, want .[1]", got)
+	}
+}
+
+func TestAscend(t *testing.T) {
+	app := newLoadedApp(t, drillInput, "name")
+	app.filter = "b"
+	app.updateFilter()
+	app.descend()
+	app.filter = "zzz"
+	app.updateFilter()
+
+	if !app.ascend() {
+		t.Fatal("ascend() = false below the top")
+	}
+	if app.filter != "b" || len(app.drill) != 0 {
+		t.Errorf("filter %q at depth %d, want b at the top", app.filter, len(app.drill))
+	}
+	if idx := app.filtered[app.cursor]; idx != 1 {
+		t.Errorf("cursor on item %d, want the item descended into", idx)
+	}
+	if app.ascend() {
+		t.Error("ascend() = true at the top")
+	}
+
+	app.descend()
+	app.descend()
+	app.ascendToTop()
+	if len(app.drill) != 0 || !slices.Equal(app.displayAttrs, []string{"name"}) {
+		t.Errorf("ascendToTop left depth %d and display attributes %q", len(app.drill), app.displayAttrs)
+	}
+}
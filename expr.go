@@ -0,0 +1,222 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// exprFunc evaluates a compiled expression against an object. It reports
+// false when an attribute is missing or not numeric, or on division by zero.
+type exprFunc func(obj map[string]interface{}) (float64, bool)
+
+// computedColumn is a virtual attribute defined with --column name=expr.
+type computedColumn struct {
+	name string
+	eval exprFunc
+}
+
+func parseColumn(spec string) (computedColumn, error) {
+	name, source, ok := strings.Cut(spec, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return computedColumn{}, fmt.Errorf("--column expects name=expression: %s", spec)
+	}
+
+	eval, err := compileExpr(source)
+	if err != nil {
+		return computedColumn{}, fmt.Errorf("invalid expression for column %s: %w", name, err)
+	}
+	return computedColumn{name: name, eval: eval}, nil
+}
+
+// compileExpr compiles an arithmetic expression over numeric attributes:
+// numbers, attribute names, + - * / %, unary minus and parentheses.
+func compileExpr(source string) (exprFunc, error) {
+	p := &exprParser{src: source}
+	fn, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, fmt.Errorf("unexpected %q", p.src[p.pos:])
+	}
+	return fn, nil
+}
+
+type exprParser struct {
+	src string
+	pos int
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// next returns the next non-blank byte without consuming it, or 0 at the end.
+func (p *exprParser) next() byte {
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *exprParser) parseSum() (exprFunc, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.next()
+		if op != '+' && op != '-' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryOp(op, left, right)
+	}
+}
+
+func (p *exprParser) parseProduct() (exprFunc, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.next()
+		if op != '*' && op != '/' && op != '%' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryOp(op, left, right)
+	}
+}
+
+func (p *exprParser) parseFactor() (exprFunc, error) {
+	switch c := p.next(); {
+	case c == 0:
+		return nil, fmt.Errorf("unexpected end of expression")
+	case c == '(':
+		p.pos++
+		inner, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ')' {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return inner, nil
+	case c == '-':
+		p.pos++
+		operand, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return func(obj map[string]interface{}) (float64, bool) {
+			v, ok := operand(obj)
+			return -v, ok
+		}, nil
+	case c >= '0' && c <= '9' || c == '.':
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] >= '0' && p.src[p.pos] <= '9' || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		n, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", p.src[start:p.pos])
+		}
+		return func(map[string]interface{}) (float64, bool) { return n, true }, nil
+	case isIdentByte(c):
+		start := p.pos
+		for p.pos < len(p.src) && (isIdentByte(p.src[p.pos]) || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		attr := p.src[start:p.pos]
+		return func(obj map[string]interface{}) (float64, bool) {
+			val, _ := lookupAttr(obj, attr)
+			return toNumber(val)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q", string(c))
+	}
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 0x80 || unicode.IsLetter(rune(c)) || c >= '0' && c <= '9'
+}
+
+func binaryOp(op byte, left, right exprFunc) exprFunc {
+	return func(obj map[string]interface{}) (float64, bool) {
+		l, ok := left(obj)
+		if !ok {
+			return 0, false
+		}
+		r, ok := right(obj)
+		if !ok {
+			return 0, false
+		}
+		switch op {
+		case '+':
+			return l + r, true
+		case '-':
+			return l - r, true
+		case '*':
+			return l * r, true
+		case '/':
+			return l / r, r != 0
+		default:
+			return math.Mod(l, r), r != 0
+		}
+	}
+}
+
+// toNumber converts a JSON value to a number. Strings holding a number are
+// converted too.
+func toNumber(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		n, err := v.Float64()
+		return n, err == nil
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestCompileExpr(t *testing.T) {
+	obj := map[string]interface{}{
+		"price": json.Number("2.5"), "qty": 4.0, "text": " 10 ", "name": "api",
+		"stock": map[string]interface{}{"count": json.Number("3")},
+	}
+	tests := []struct {
+		expr string
+		want float64
+		ok   bool
+	}{
+		{"price*qty", 10, true},
+		{"1 + 2 * 3", 7, true},
+		{"(1 + 2) * 3", 9, true},
+		{"10 - 4 - 3", 3, true},
+		{"-price + 1", -1.5, true},
+		{"qty % 3", 1, true},
+		{"qty / 8", 0.5, true},
+		{"text * 2", 20, true},
+		{"stock.count * qty", 12, true},
+		{"qty / 0", 0, false},
+		{"qty % 0", 0, false},
+		{"name * 2", 0, false},
+		{"missing + 1", 0, false},
+	}
+	for _, tt := range tests {
+		eval, err := compileExpr(tt.expr)
+		if err != nil {
+			t.Errorf("compileExpr(%q): %v", tt.expr, err)
+			continue
+		}
+		if got, ok := eval(obj); ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("%s = %v, %v; want %v, %v", tt.expr, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCompileExprErrors(t *testing.T) {
+	for _, expr := range []string{"", "price *", "(price", "price)", "1..2", "price # 2", "*"} {
+		if _, err := compileExpr(expr); err == nil {
+			t.Errorf("compileExpr(%q) accepted an invalid expression", expr)
+		}
+	}
+}
+
+func TestParseColumn(t *testing.T) {
+	col, err := parseColumn(" total = price*qty")
+	if err != nil || col.name != "total" {
+		t.Fatalf("parseColumn() = %q, %v; want total", col.name, err)
+	}
+	for _, spec := range []string{"price*qty", "=price", "total=", "total=price+"} {
+		if _, err := parseColumn(spec); err == nil {
+			t.Errorf("parseColumn(%q) accepted an invalid column", spec)
+		}
+	}
+}
+
+func TestComputedColumnMatchesOutput(t *testing.T) {
+	objects := []map[string]interface{}{
+		{"price": json.Number("1500"), "qty": json.Number("1000")},
+		{"price": json.Number("0.5"), "qty": json.Number("3")},
+	}
+	app := newApp(objects, []string{"total"}, "total", nil, false, false, " - ")
+	col, err := parseColumn("total=price*qty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.columns = []computedColumn{col}
+
+	for i, want := range []string{"1500000", "1.5"} {
+		if got := app.getDisplayValue(objects[i]); got != want {
+			t.Errorf("item %d shows %q, want %q", i, got, want)
+		}
+		val, _ := app.attrValue(objects[i], "total")
+		if got, err := formatOutputValue(val); err != nil || got != want {
+			t.Errorf("item %d outputs %q, %v; want %q", i, got, err, want)
+		}
+	}
+	for query, want := range map[string][]int{"1500000": {0}, "1.5e": nil, "1.5": {1}} {
+		if got, err := app.matchItems([]int{0, 1}, query); err != nil || !slices.Equal(got, want) {
+			t.Errorf("matchItems(%s) = %v, %v; want %v", query, got, err, want)
+		}
+	}
+}
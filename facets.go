@@ -0,0 +1,236 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// facetBar is the sidebar listing the values of an attribute among the
+// items matching the filter, with their counts. Choosing values narrows
+// the list to the items having one of them.
+type facetBar struct {
+	attrs  []string
+	attr   int
+	values []valueCount
+	chosen map[string]bool
+	cursor int
+	focus  bool // whether keys move through the values rather than the list
+}
+
+// facetMinWidth is the narrowest terminal the sidebar is shown in.
+const facetMinWidth = 60
+
+// openFacets shows the sidebar for attr, or for the first display
+// attribute, or the first attribute of the items when whole objects are
+// displayed.
+func (a *App) openFacets(attr string) {
+	attrs := slices.Clone(a.displayAttrs)
+	if len(attrs) == 0 {
+		attrs = getAllAttributes(a.objects)
+	}
+	if attr != "" {
+		attrs = slices.DeleteFunc(attrs, func(s string) bool { return s == attr })
+		attrs = append([]string{attr}, attrs...)
+	}
+	if len(attrs) == 0 {
+		return
+	}
+	a.facets = &facetBar{attrs: attrs, chosen: make(map[string]bool)}
+	a.updateFilter()
+}
+
+// toggleFacets shows the sidebar, with the keys moving through its values,
+// or hides it, dropping the values chosen.
+func (a *App) toggleFacets() {
+	if a.facets != nil {
+		a.facets = nil
+		a.updateFilter()
+		return
+	}
+	a.openFacets(a.facetAttr)
+	if a.facets != nil {
+		a.facets.focus = true
+	}
+}
+
+// focusFacets moves the keys between the list and the sidebar, showing the
+// sidebar first if needed.
+func (a *App) focusFacets() {
+	if a.facets == nil {
+		a.toggleFacets()
+		return
+	}
+	a.facets.focus = !a.facets.focus
+}
+
+// narrowFacets counts the values of the facet attribute among matches, the
+// items matching the filter, and returns those having one of the chosen
+// values, or all of them when none is chosen.
+func (a *App) narrowFacets(matches []int) []int {
+	f := a.facets
+	attr := f.attrs[f.attr]
+
+	counts := make(map[string]int)
+	values := make(map[int]string, len(matches))
+	for _, idx := range matches {
+		val, ok := a.attrValue(a.objects[idx], attr)
+		if !ok {
+			continue
+		}
+		formatted, err := formatOutputValue(val)
+		if err != nil {
+			continue
+		}
+		counts[formatted]++
+		values[idx] = formatted
+	}
+	// Chosen values stay listed, so that they can be dropped, even when
+	// no match has them anymore
+	for value := range f.chosen {
+		counts[value] += 0
+	}
+
+	// The cursor stays on its value as the counts change
+	current := ""
+	if f.cursor < len(f.values) {
+		current = f.values[f.cursor].value
+	}
+	f.values = f.values[:0]
+	for value, count := range counts {
+		f.values = append(f.values, valueCount{value: value, count: count})
+	}
+	sort.Slice(f.values, func(i, j int) bool {
+		if f.values[i].count != f.values[j].count {
+			return f.values[i].count > f.values[j].count
+		}
+		return f.values[i].value < f.values[j].value
+	})
+	f.cursor = min(f.cursor, max(0, len(f.values)-1))
+	for i, v := range f.values {
+		if v.value == current {
+			f.cursor = i
+		}
+	}
+
+	if len(f.chosen) == 0 {
+		return matches
+	}
+	narrowed := []int{}
+	for _, idx := range matches {
+		if value, ok := values[idx]; ok && f.chosen[value] {
+			narrowed = append(narrowed, idx)
+		}
+	}
+	return narrowed
+}
+
+// handleFacetKey handles a key while the sidebar has the focus. It reports
+// false for keys left to the list, such as those typing into the filter.
+func (a *App) handleFacetKey(key string) bool {
+	f := a.facets
+	switch key {
+	case "up":
+		f.cursor = max(0, f.cursor-1)
+	case "down":
+		f.cursor = max(0, min(len(f.values)-1, f.cursor+1))
+	case "enter", " ":
+		if f.cursor < len(f.values) {
+			value := f.values[f.cursor].value
+			if f.chosen[value] {
+				delete(f.chosen, value)
+			} else {
+				f.chosen[value] = true
+			}
+			a.updateFilter()
+		}
+	case "tab", "btab":
+		step := 1
+		if key == "btab" {
+			step = len(f.attrs) - 1
+		}
+		f.attr = (f.attr + step) % len(f.attrs)
+		f.chosen = make(map[string]bool)
+		f.cursor = 0
+		a.updateFilter()
+	case "esc":
+		f.focus = false
+	default:
+		return false
+	}
+	return true
+}
+
+// facetWidth returns the number of columns taken by the sidebar.
+func (a *App) facetWidth() int {
+	if a.facets == nil || a.width < facetMinWidth {
+		return 0
+	}
+	return min(40, a.width/4)
+}
+
+// renderFacets draws the sidebar over the right part of the rows left of
+// the preview pane.
+func (a *App) renderFacets(frame *bytes.Buffer) {
+	width := a.facetWidth()
+	if width == 0 {
+		return
+	}
+	f := a.facets
+	left := a.listWidth() + 1
+	lines := []string{fmt.Sprintf("%s%s%s", colorInfo, f.attrs[f.attr], colorReset)}
+	if len(f.values) == 0 {
+		lines = append(lines, "  (no values)")
+	}
+
+	// Keep the cursor in view below the attribute name
+	rows := a.height - 2
+	start := 0
+	if f.cursor >= rows {
+		start = f.cursor - rows + 1
+	}
+	for i := start; i < len(f.values) && len(lines) <= rows; i++ {
+		v := f.values[i]
+		mark := "[ ]"
+		if f.chosen[v.value] {
+			mark = "[x]"
+		}
+		count := fmt.Sprintf(" %d", v.count)
+		value := truncateWidth(v.value, max(3, width-6-len(count)))
+		row := mark + " " + value + strings.Repeat(" ", max(0, width-6-len(count)-displayWidth(value))) + count
+		if f.focus && i == f.cursor {
+			row = colorReverse + row + colorReset
+		}
+		lines = append(lines, row)
+	}
+
+	for row := 1; row < a.height; row++ {
+		a.moveTo(frame, row, left)
+		fmt.Fprintf(frame, "%s%s│%s ", clearToEOL, colorInfo, colorReset)
+		if row-1 < len(lines) {
+			fmt.Fprint(frame, lines[row-1])
+		}
+	}
+}
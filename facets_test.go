@@ -0,0 +1,83 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFacets(t *testing.T) {
+	app := newLoadedApp(t, kindInput, "name", "kind")
+	app.openFacets("kind")
+	want := []valueCount{{"x", 2}, {"y", 2}}
+	if got := app.facets.values; !slices.Equal(got, want) {
+		t.Errorf("values %v, want %v", got, want)
+	}
+
+	// Choosing values narrows the list to the items having one of them
+	app.facets.focus = true
+	app.handleFacetKey("down")
+	app.handleFacetKey("enter")
+	if got := listedNames(app); !slices.Equal(got, []string{"b", "e"}) {
+		t.Errorf("listed %q with y chosen", got)
+	}
+	app.handleFacetKey("up")
+	app.handleFacetKey(" ")
+	if got := listedNames(app); !slices.Equal(got, []string{"a", "b", "c", "e"}) {
+		t.Errorf("listed %q with x and y chosen", got)
+	}
+
+	// The counts follow the filter, and chosen values stay listed
+	app.filter = "b"
+	app.updateFilter()
+	want = []valueCount{{"y", 1}, {"x", 0}}
+	if got := app.facets.values; !slices.Equal(got, want) {
+		t.Errorf("values %v for b, want %v", got, want)
+	}
+	if app.handleFacetKey("x") {
+		t.Error("handleFacetKey took a key typed into the filter")
+	}
+}
+
+func TestFacetsNextAttribute(t *testing.T) {
+	app := newLoadedApp(t, kindInput, "name", "kind")
+	app.openFacets("kind")
+	app.facets.chosen["x"] = true
+	app.updateFilter()
+
+	app.handleFacetKey("tab")
+	if attr := app.facets.attrs[app.facets.attr]; attr != "name" || len(app.facets.chosen) != 0 {
+		t.Errorf("tab moved to %s with %v chosen, want name with none", attr, app.facets.chosen)
+	}
+	if len(app.filtered) != 5 || len(app.facets.values) != 5 {
+		t.Errorf("listed %d items and %d values, want all 5", len(app.filtered), len(app.facets.values))
+	}
+	app.handleFacetKey("btab")
+	if attr := app.facets.attrs[app.facets.attr]; attr != "kind" {
+		t.Errorf("btab moved to %s, want kind", attr)
+	}
+
+	app.toggleFacets()
+	if app.facets != nil {
+		t.Error("toggleFacets() left the sidebar open")
+	}
+}
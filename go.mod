@@ -4,6 +4,20 @@ go 1.24.0
 
 toolchain go1.24.11
 
-require golang.org/x/term v0.37.0
+require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/itchyny/gojq v0.12.19
+	github.com/klauspost/compress v1.18.0
+	github.com/rivo/uniseg v0.4.7
+	github.com/tidwall/gjson v1.19.0
+	golang.org/x/sys v0.38.0
+	golang.org/x/term v0.37.0
+	golang.org/x/text v0.30.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
-require golang.org/x/sys v0.38.0 // indirect
+require (
+	github.com/itchyny/timefmt-go v0.1.8 // indirect
+	github.com/tidwall/match v1.1.1 // indirect
+	github.com/tidwall/pretty v1.2.0 // indirect
+)
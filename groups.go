@@ -0,0 +1,178 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// noGroup is the group of the items without the --group-by attribute.
+// It can't be the formatted value of an attribute.
+const noGroup = "\x00"
+
+// groupKey returns the group of the item at idx with --group-by: the value
+// of the attribute as -o prints it.
+func (a *App) groupKey(idx int) string {
+	val, ok := a.attrValue(a.objects[idx], a.groupBy)
+	if !ok {
+		return noGroup
+	}
+	key, err := formatOutputValue(val)
+	if err != nil {
+		return noGroup
+	}
+	return key
+}
+
+// groupFiltered gathers the filtered items into groups, in the order their
+// first item comes in, keeping the order of the items within each group.
+// Only the first item of a collapsed group is kept, to show its header.
+func (a *App) groupFiltered() {
+	keys := make(map[int]string, len(a.filtered))
+	rank := make(map[string]int)
+	a.groupCounts = make(map[string]int)
+	for _, idx := range a.filtered {
+		key := a.groupKey(idx)
+		keys[idx] = key
+		if _, ok := rank[key]; !ok {
+			rank[key] = len(rank)
+		}
+		a.groupCounts[key]++
+	}
+	sort.SliceStable(a.filtered, func(i, j int) bool {
+		return rank[keys[a.filtered[i]]] < rank[keys[a.filtered[j]]]
+	})
+
+	if len(a.collapsed) == 0 {
+		return
+	}
+	kept := a.filtered[:0]
+	for i, idx := range a.filtered {
+		key := keys[idx]
+		if !a.collapsed[key] || i == 0 || keys[a.filtered[i-1]] != key {
+			kept = append(kept, idx)
+		}
+	}
+	a.filtered = kept
+}
+
+// groupStart tells whether the item at position i of the filtered list is
+// the first of its group, under the group's header.
+func (a *App) groupStart(i int) bool {
+	if a.groupBy == "" {
+		return false
+	}
+	return i == 0 || a.groupKey(a.filtered[i-1]) != a.groupKey(a.filtered[i])
+}
+
+// groupCollapsed tells whether the item at position i of the filtered list
+// stands for its collapsed group, showing only the header.
+func (a *App) groupCollapsed(i int) bool {
+	return a.groupBy != "" && a.collapsed[a.groupKey(a.filtered[i])]
+}
+
+// groupHeader returns the header of the group starting at position i of
+// the filtered list: the group value and the number of its items.
+func (a *App) groupHeader(i int) string {
+	key := a.groupKey(a.filtered[i])
+	marker := "▾"
+	if a.collapsed[key] {
+		marker = "▸"
+	}
+	label := key
+	if key == noGroup {
+		label = "(none)"
+	}
+	return fmt.Sprintf("%s %s: %s (%d)", marker, a.groupBy, label, a.groupCounts[key])
+}
+
+// itemLines returns the number of lines taken by the item at position i of
+// the filtered list, whose display value is displayVal, along with the
+// header of its group when it starts one.
+func (a *App) itemLines(i int, displayVal string) int {
+	if a.groupCollapsed(i) {
+		return 1
+	}
+	lines := a.calculateLines(displayVal)
+	if a.groupStart(i) {
+		lines++
+	}
+	return lines
+}
+
+// setGroupCollapsed collapses or expands the group of the item under the
+// cursor, which moves to the group's header. It reports false when the
+// group already was in that state.
+func (a *App) setGroupCollapsed(collapsed bool) bool {
+	if a.groupBy == "" || a.cursor >= len(a.filtered) {
+		return false
+	}
+	key := a.groupKey(a.filtered[a.cursor])
+	if a.collapsed[key] == collapsed {
+		return false
+	}
+	if collapsed {
+		a.collapsed[key] = true
+	} else {
+		delete(a.collapsed, key)
+	}
+	a.updateFilter()
+	for i, idx := range a.filtered {
+		if a.groupKey(idx) == key {
+			a.cursor = i
+			break
+		}
+	}
+	return true
+}
+
+// toggleGroup collapses or expands the group of the item under the cursor.
+func (a *App) toggleGroup() {
+	if !a.setGroupCollapsed(true) {
+		a.setGroupCollapsed(false)
+	}
+}
+
+// jumpGroup moves the cursor to the first item of the next group, or with
+// a negative step to the first item of the current group, or of the
+// previous one when already there.
+func (a *App) jumpGroup(step int) {
+	if a.groupBy == "" || len(a.filtered) == 0 {
+		return
+	}
+	i := a.cursor
+	if step > 0 {
+		for i++; i < len(a.filtered) && !a.groupStart(i); i++ {
+		}
+		if i < len(a.filtered) {
+			a.cursor = i
+		}
+		return
+	}
+	if a.groupStart(i) && i > 0 {
+		i--
+	}
+	for i > 0 && !a.groupStart(i) {
+		i--
+	}
+	a.cursor = i
+}
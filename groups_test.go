@@ -0,0 +1,96 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func newGroupApp(t *testing.T) *App {
+	t.Helper()
+	app := newLoadedApp(t, kindInput, "name")
+	app.groupBy = "kind"
+	app.collapsed = make(map[string]bool)
+	app.updateFilter()
+	return app
+}
+
+func TestGroupFiltered(t *testing.T) {
+	app := newGroupApp(t)
+	if got, want := listedNames(app), []string{"a", "c", "b", "e", "d"}; !slices.Equal(got, want) {
+		t.Errorf("listed %q, want %q", got, want)
+	}
+	var headers []string
+	for i := range app.filtered {
+		if app.groupStart(i) {
+			headers = append(headers, app.groupHeader(i))
+		}
+	}
+	want := []string{"▾ kind: x (2)", "▾ kind: y (2)", "▾ kind: (none) (1)"}
+	if !slices.Equal(headers, want) {
+		t.Errorf("headers %q, want %q", headers, want)
+	}
+
+	app.filter = "e"
+	app.updateFilter()
+	if got := listedNames(app); !slices.Equal(got, []string{"e"}) {
+		t.Errorf("listed %q for e", got)
+	}
+}
+
+func TestCollapseGroup(t *testing.T) {
+	app := newGroupApp(t)
+	app.cursor = 3 // e
+	if !app.setGroupCollapsed(true) {
+		t.Fatal("setGroupCollapsed(true) = false on an expanded group")
+	}
+	if got, want := listedNames(app), []string{"a", "c", "b", "d"}; !slices.Equal(got, want) {
+		t.Errorf("listed %q, want %q", got, want)
+	}
+	if app.cursor != 2 || !app.groupCollapsed(2) || app.groupHeader(2) != "▸ kind: y (2)" {
+		t.Errorf("cursor on %d, want the header of the collapsed group", app.cursor)
+	}
+	if app.setGroupCollapsed(true) {
+		t.Error("setGroupCollapsed(true) = true on a collapsed group")
+	}
+	app.toggleGroup()
+	if got := listedNames(app); len(got) != 5 {
+		t.Errorf("listed %q after expanding the group again", got)
+	}
+}
+
+func TestJumpGroup(t *testing.T) {
+	app := newGroupApp(t)
+	var visited []int
+	for range 3 {
+		app.jumpGroup(1)
+		visited = append(visited, app.cursor)
+	}
+	app.cursor = 3
+	for range 3 {
+		app.jumpGroup(-1)
+		visited = append(visited, app.cursor)
+	}
+	if want := []int{2, 4, 4, 2, 0, 0}; !slices.Equal(visited, want) {
+		t.Errorf("visited %v, want %v", visited, want)
+	}
+}
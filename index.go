@@ -0,0 +1,141 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// openIndex indexes the NDJSON file of cfg for --index, for inputs too
+// large to hold in memory. The file is read a line at a time and only the
+// attributes indexAttrs names are kept of each object; the paths of the
+// items hold where each object is in the file, so that it can be read
+// again when the whole object is needed. It returns the file, which stays
+// open for that.
+func openIndex(cfg config) (*os.File, []map[string]interface{}, []itemPath, error) {
+	file, err := os.Open(cfg.filename)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	objects, paths, err := indexLines(file, indexAttrs(cfg))
+	if err != nil {
+		file.Close()
+		return nil, nil, nil, err
+	}
+	return file, objects, paths, nil
+}
+
+// indexLines reads the objects of an NDJSON input, one per line, keeping
+// only attrs of each.
+func indexLines(r io.Reader, attrs []string) ([]map[string]interface{}, []itemPath, error) {
+	var objects []map[string]interface{}
+	var paths []itemPath
+	reader := bufio.NewReaderSize(r, 64*1024)
+	var offset int64
+	for line := 1; ; line++ {
+		text, err := reader.ReadBytes('\n')
+		start := offset
+		offset += int64(len(text))
+		if trimmed := bytes.TrimSpace(text); len(trimmed) > 0 {
+			obj, decodeErr := decodeElement(trimmed)
+			if decodeErr != nil {
+				return nil, nil, fmt.Errorf("line %d: %w", line, decodeErr)
+			}
+			objects = append(objects, indexedObject(obj, attrs))
+			paths = append(paths, itemPath{
+				object: fmt.Sprintf(".[%d]", len(paths)),
+				offset: start,
+				size:   len(text),
+			})
+		}
+		if err == io.EOF {
+			return objects, paths, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// indexAttrs returns the attributes kept of the objects with --index: those
+// displayed, output, sorted or grouped by, counted in the facet sidebar,
+// or identifying items.
+func indexAttrs(cfg config) []string {
+	attrs := slices.Concat(cfg.displayAttrs, cfg.outputAttrs)
+	if attr, _, ok := strings.Cut(cfg.defaultMatch, "="); ok {
+		attrs = append(attrs, attr)
+	}
+	sortAttr := strings.TrimPrefix(cfg.sortAttr, "-")
+	for _, attr := range []string{cfg.key, sortAttr, cfg.groupBy, cfg.facet, cfg.uniqueBy, cfg.linkField} {
+		if attr != "" {
+			attrs = append(attrs, attr)
+		}
+	}
+	return attrs
+}
+
+// indexedObject returns the attributes attrs of obj, under their names,
+// which may be paths. Items made from values other than objects are kept
+// whole.
+func indexedObject(obj map[string]interface{}, attrs []string) map[string]interface{} {
+	if _, ok := elementValue(obj); ok {
+		return obj
+	}
+	indexed := make(map[string]interface{}, len(attrs))
+	for _, attr := range attrs {
+		if v, ok := lookupAttr(obj, attr); ok {
+			indexed[attr] = v
+		}
+	}
+	return indexed
+}
+
+// readIndexed reads the text of an object indexed with --index from the
+// input file.
+func (a *App) readIndexed(path itemPath) (json.RawMessage, error) {
+	text := make([]byte, path.size)
+	if _, err := a.indexFile.ReadAt(text, path.offset); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(text), nil
+}
+
+// object returns the object at idx. With --index, the objects only hold the
+// indexed attributes, and the whole object is read from the input file. In
+// --tree mode, the value of an object or array node is decoded from its text.
+func (a *App) object(idx int) map[string]interface{} {
+	if idx < len(a.paths) && a.paths[idx].size > 0 {
+		if obj, err := decodeElement(a.rawObject(idx)); err == nil {
+			return obj
+		}
+	}
+	if a.treeMode {
+		return treeNode(a.objects[idx])
+	}
+	return a.objects[idx]
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIndexReadsObjectsOnDemand(t *testing.T) {
+	input := "{\"name\":\"apple\",\"meta\":{\"id\":1},\"body\":\"long\"}\n\n\"plain\"\r\n{\"name\":\"banana\"}"
+	filename := filepath.Join(t.TempDir(), "items.ndjson")
+	if err := os.WriteFile(filename, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := parseArgs([]string{filename, "--index", "-d", "name", "--key", "meta.id"}, configFile{})
+	file, objects, paths, err := openIndex(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	indexed := []map[string]interface{}{
+		{"name": "apple", "meta.id": json.Number("1")},
+		{elementKey: "plain"},
+		{"name": "banana"},
+	}
+	if !reflect.DeepEqual(objects, indexed) {
+		t.Errorf("indexed objects = %v, want %v", objects, indexed)
+	}
+
+	app := newApp(objects, cfg.displayAttrs, "", nil, false, false, " - ")
+	app.paths, app.indexFile = paths, file
+	raws := []string{`{"name":"apple","meta":{"id":1},"body":"long"}`, `"plain"`, `{"name":"banana"}`}
+	for i, want := range raws {
+		if got := string(app.rawObject(i)); got != want {
+			t.Errorf("rawObject(%d) = %s, want %s", i, got, want)
+		}
+		if got := app.jqPath(i); got != paths[i].object {
+			t.Errorf("jqPath(%d) = %s, want %s", i, got, paths[i].object)
+		}
+	}
+	if body := app.object(0)["body"]; body != "long" {
+		t.Errorf("object(0) has body %v, want long", body)
+	}
+}
@@ -0,0 +1,293 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/itchyny/gojq"
+)
+
+// runJQ runs the --jq program of cfg on input and returns its results as
+// JSON input, along with cfg set up to read it. A single array result is
+// used as the input itself; any other results, even a single object as
+// from .items[] | select(.ok), are gathered into an array of records.
+func runJQ(input []byte, cfg config) ([]byte, config, error) {
+	results, cfg, err := jqResults(input, cfg)
+	if err != nil {
+		return nil, cfg, err
+	}
+	var output interface{} = results
+	if len(results) == 1 {
+		if array, ok := results[0].([]interface{}); ok {
+			output = array
+		}
+	}
+	data, err := gojq.Marshal(output)
+	if err != nil {
+		return nil, cfg, fmt.Errorf("--jq: %w", err)
+	}
+	return data, cfg, nil
+}
+
+// jqResults runs the --jq program of cfg on input and returns its results,
+// along with cfg set up to read them as JSON. The program runs on every
+// JSON value of the input, as jq does, or on the array of objects read
+// from input in another format.
+func jqResults(input []byte, cfg config) ([]interface{}, config, error) {
+	code, err := compileJQ(cfg.jq)
+	if err != nil {
+		return nil, cfg, fmt.Errorf("invalid --jq: %w", err)
+	}
+
+	var values []interface{}
+	format := cfg
+	if err := detectFormat(&format, input); err != nil {
+		return nil, cfg, err
+	}
+	if format.yaml || format.toml || format.csv || format.tsv {
+		_, raws, err := parseObjects(input, format)
+		if err != nil && !errors.Is(err, errNoObjects) {
+			return nil, cfg, err
+		}
+		objects := make([]interface{}, len(raws))
+		for i, raw := range raws {
+			if objects[i], err = decodeJQValue(raw); err != nil {
+				return nil, cfg, err
+			}
+		}
+		values = append(values, objects)
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(input))
+		decoder.UseNumber()
+		for {
+			var value interface{}
+			err := decoder.Decode(&value)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, cfg, fmt.Errorf("error parsing JSON: %w", err)
+			}
+			values = append(values, value)
+		}
+	}
+
+	results := []interface{}{}
+	for _, value := range values {
+		iter := code.Run(value)
+		for {
+			result, ok := iter.Next()
+			if !ok {
+				break
+			}
+			if err, ok := result.(error); ok {
+				return nil, cfg, fmt.Errorf("--jq: %w", err)
+			}
+			results = append(results, result)
+		}
+	}
+
+	// The results are JSON, whatever the input was
+	cfg.jq = ""
+	cfg.yaml, cfg.toml, cfg.csv, cfg.tsv = false, false, false, false
+	cfg.filename, cfg.url = "", ""
+	return results, cfg, nil
+}
+
+// decodeJQValue decodes JSON text into a value for gojq, keeping numbers
+// as written.
+func decodeJQValue(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	err := decoder.Decode(&value)
+	return value, err
+}
+
+// compileJQ compiles the jq program expr.
+func compileJQ(expr string) (*gojq.Code, error) {
+	query, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	return gojq.Compile(query)
+}
+
+// isJQExpr tells whether attr is a jq expression evaluated per object, such
+// as .name or .meta.name + " (" + .status + ")", rather than the name of an
+// attribute. jq expressions start with a dot.
+func isJQExpr(attr string) bool {
+	return strings.HasPrefix(attr, ".")
+}
+
+// evalJQ evaluates the jq expression expr on obj and returns its first
+// result. It reports no value when expr has no results or fails.
+func (a *App) evalJQ(obj map[string]interface{}, expr string) (interface{}, bool) {
+	code, ok := a.jqExprs[expr]
+	if !ok {
+		code, _ = compileJQ(expr)
+		if a.jqExprs == nil {
+			a.jqExprs = make(map[string]*gojq.Code)
+		}
+		a.jqExprs[expr] = code
+	}
+	if code == nil {
+		return nil, false
+	}
+
+	var input interface{} = obj
+	if v, ok := elementValue(obj); ok {
+		input = v
+	}
+	result, ok := code.Run(input).Next()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := result.(error); ok {
+		return nil, false
+	}
+	return jqNumber(result), true
+}
+
+// jqNumber turns the integers gojq computes into json.Number, the type of
+// numbers read from the input, and returns other values as they are.
+func jqNumber(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return json.Number(strconv.Itoa(n))
+	case *big.Int:
+		return json.Number(n.String())
+	}
+	return v
+}
+
+// jqRowTimeout bounds the time a jq expression runs on one item in jq
+// mode, so that a runaway expression doesn't freeze the picker.
+const jqRowTimeout = 100 * time.Millisecond
+
+// maxJQResults is the number of results of a jq expression shown per item.
+const maxJQResults = 100
+
+// toggleJQMode switches the prompt between the filter and a jq expression
+// evaluated on every item. Each mode keeps its own text.
+func (a *App) toggleJQMode() {
+	a.jqMode = !a.jqMode
+	a.filter, a.savedFilter = a.savedFilter, a.filter
+	if a.jqMode && a.filter == "" {
+		a.filter = "."
+	}
+	a.jqCode = nil
+	a.filterErr = ""
+	a.updateFilter()
+}
+
+// matchJQ compiles the jq expression expr and returns the candidates it has
+// results for, which are shown instead of the display attributes. An empty
+// expression is the identity. When expr doesn't compile, the previous
+// expression stays in effect.
+func (a *App) matchJQ(candidates []int, expr string) ([]int, error) {
+	if strings.TrimSpace(expr) == "" {
+		expr = "."
+	}
+	code, err := compileJQ(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq: %v", err)
+	}
+	a.jqCode = code
+
+	matches := []int{}
+	for _, i := range candidates {
+		if _, ok := a.jqRow(a.objects[i]); ok {
+			matches = append(matches, i)
+		}
+	}
+	return matches, nil
+}
+
+// jqResults runs the jq mode expression on obj and returns its results, up
+// to maxJQResults.
+func (a *App) jqResults(obj map[string]interface{}) ([]interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), jqRowTimeout)
+	defer cancel()
+
+	var input interface{} = obj
+	if v, ok := elementValue(obj); ok {
+		input = v
+	}
+	var results []interface{}
+	iter := a.jqCode.RunWithContext(ctx, input)
+	for len(results) < maxJQResults {
+		result, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := result.(error); ok {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// jqRow returns the row shown for obj in jq mode: the results of the
+// expression as single-line JSON, or the error it fails with. It reports
+// false when there are neither, so that the item is hidden.
+func (a *App) jqRow(obj map[string]interface{}) (string, bool) {
+	results, err := a.jqResults(obj)
+	var parts []string
+	for _, result := range results {
+		data, _ := gojq.Marshal(result)
+		parts = append(parts, string(data))
+	}
+	if err != nil {
+		parts = append(parts, "error: "+err.Error())
+	}
+	return strings.Join(parts, ", "), len(parts) > 0
+}
+
+// outputJQResults prints the results of the jq mode expression for the
+// items at indices, one per line, as -o prints values.
+func (a *App) outputJQResults(indices []int) error {
+	for _, idx := range indices {
+		results, err := a.jqResults(a.objects[idx])
+		if err != nil {
+			return fmt.Errorf("jq: %w", err)
+		}
+		for _, result := range results {
+			out, err := a.formatOutput(jqNumber(result))
+			if err != nil {
+				return err
+			}
+			fmt.Println(out)
+		}
+	}
+	return nil
+}
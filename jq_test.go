@@ -0,0 +1,99 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRunJQSingleResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		jq      string
+		items   int
+		entries bool
+	}{
+		{"one object from a stream", `[{"name":"a","qty":1}]`, ".[] | .qty += 1", 1, false},
+		{"one selected item", `{"items":[{"ok":true},{"ok":false}]}`, ".items[] | select(.ok)", 1, false},
+		{"several objects", `{"items":[{"ok":true},{"ok":true}]}`, ".items[] | select(.ok)", 2, false},
+		{"single array", `{"items":[{"a":1},{"a":2},{"a":3}]}`, ".items", 3, false},
+		{"strings", `[{"n":"a"},{"n":"b"}]`, ".[].n", 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config{jq: tt.jq}
+			objects, _, err := decodeInput([]byte(tt.input), &cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(objects) != tt.items || cfg.entries != tt.entries {
+				t.Errorf("got %d items, entries %v; want %d, %v", len(objects), cfg.entries, tt.items, tt.entries)
+			}
+			if _, ok := lookupAttr(objects[0], "key"); ok {
+				t.Errorf("item 0 is an entry: %v", objects[0])
+			}
+		})
+	}
+}
+
+func TestTreeJQSingleResult(t *testing.T) {
+	objects, _, err := treeItems([]byte(`{"spec":{"a":1,"b":2}}`), config{jq: ".spec"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 3 || objects[0]["path"] != "." || objects[1]["path"] != ".a" {
+		t.Errorf("got %d nodes, first %v; want the members of .spec", len(objects), objects[0]["path"])
+	}
+}
+
+func TestJQMode(t *testing.T) {
+	app := newLoadedApp(t, kindInput, "name")
+	app.filter = "c"
+	app.updateFilter()
+
+	app.toggleJQMode()
+	if app.filter != "." || len(app.filtered) != 5 {
+		t.Fatalf("jq mode starts with %q listing %d items, want . listing all", app.filter, len(app.filtered))
+	}
+	app.filter = `select(.kind == "y") | .name, .kind`
+	app.updateFilter()
+	var rows []string
+	for _, idx := range app.filtered {
+		rows = append(rows, app.getDisplayValue(app.objects[idx]))
+	}
+	if want := []string{`"b", "y"`, `"e", "y"`}; !slices.Equal(rows, want) {
+		t.Errorf("rows %q, want %q", rows, want)
+	}
+
+	// An expression being typed keeps the last one in effect
+	app.filter = `select(.kind ==`
+	app.updateFilter()
+	if app.filterErr == "" || len(app.filtered) != 2 {
+		t.Errorf("incomplete expression listed %d items with error %q", len(app.filtered), app.filterErr)
+	}
+
+	app.toggleJQMode()
+	if app.filter != "c" || app.filterErr != "" || !slices.Equal(listedNames(app), []string{"c"}) {
+		t.Errorf("leaving jq mode restored %q listing %q", app.filter, listedNames(app))
+	}
+}
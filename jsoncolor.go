@@ -0,0 +1,110 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// colorKey is the color of object keys in colorized JSON output.
+const colorKey = "\033[1;34m"
+
+// colorizeJSON pretty-prints v with keys, strings and literals in color,
+// for output read by a human on a terminal.
+func colorizeJSON(v interface{}) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return colorizeIndented(data), nil
+}
+
+// colorizeRaw is colorizeJSON for JSON text, which keeps its key order and
+// the way its values are written.
+func colorizeRaw(raw json.RawMessage) (string, error) {
+	var data bytes.Buffer
+	if err := json.Indent(&data, raw, "", "  "); err != nil {
+		return "", err
+	}
+	return colorizeIndented(data.Bytes()), nil
+}
+
+// colorizeIndented colors indented JSON text.
+func colorizeIndented(data []byte) string {
+	var out bytes.Buffer
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '"':
+			end := stringEnd(data, i)
+			color := colorGreen
+			if isObjectKey(data, end) {
+				color = colorKey
+			}
+			out.WriteString(color)
+			out.Write(data[i:end])
+			out.WriteString(colorReset)
+			i = end
+		case c == 't' || c == 'f' || c == 'n':
+			end := i
+			for end < len(data) && data[end] >= 'a' && data[end] <= 'z' {
+				end++
+			}
+			out.WriteString(colorInfo)
+			out.Write(data[i:end])
+			out.WriteString(colorReset)
+			i = end
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.String()
+}
+
+// stringEnd returns the offset just past the JSON string starting at start.
+func stringEnd(data []byte, start int) int {
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(data)
+}
+
+// isObjectKey reports whether the string ending at end is followed by a
+// colon, making it an object key.
+func isObjectKey(data []byte, end int) bool {
+	for i := end; i < len(data); i++ {
+		switch data[i] {
+		case ' ', '\n':
+			continue
+		case ':':
+			return true
+		}
+		return false
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestColorizeRaw(t *testing.T) {
+	k := func(s string) string { return colorKey + `"` + s + `"` + colorReset }
+	str := func(s string) string { return colorGreen + `"` + s + `"` + colorReset }
+	lit := func(s string) string { return colorInfo + s + colorReset }
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "keys and values",
+			input: `{"name":"a","n":1.50,"ok":true,"none":null}`,
+			want: []string{
+				"{",
+				"  " + k("name") + `: ` + str("a") + ",",
+				"  " + k("n") + `: 1.50,`,
+				"  " + k("ok") + `: ` + lit("true") + ",",
+				"  " + k("none") + `: ` + lit("null"),
+				"}",
+			},
+		},
+		{
+			name:  "strings that look like keys",
+			input: `["a:b", "x\"y", false]`,
+			want: []string{
+				"[",
+				"  " + str("a:b") + ",",
+				"  " + str(`x\"y`) + ",",
+				"  " + lit("false"),
+				"]",
+			},
+		},
+		{
+			name:  "nested",
+			input: `{"tags":{"t":["u"]}}`,
+			want: []string{
+				"{",
+				"  " + k("tags") + ": {",
+				"    " + k("t") + ": [",
+				"      " + str("u"),
+				"    ]",
+				"  }",
+				"}",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := colorizeRaw(json.RawMessage(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := strings.Join(tt.want, "\n"); got != want {
+				t.Errorf("got\n%q\nwant\n%q", got, want)
+			}
+		})
+	}
+}
+
+func TestColorizeJSON(t *testing.T) {
+	got, err := colorizeJSON(map[string]interface{}{"b": []interface{}{}, "a": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  " + colorKey + `"a"` + colorReset + ": " + colorGreen + `"x"` + colorReset + ",\n  " +
+		colorKey + `"b"` + colorReset + ": []\n}"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, err := colorizeRaw(json.RawMessage(`{"a":`)); err == nil {
+		t.Error("colorizeRaw accepted invalid JSON")
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// escapeSequences maps the escape sequences sent by common terminals to key
+// names usable with --bind.
+var escapeSequences = map[string]string{
+	"\x1b[A":    "up",
+	"\x1bOA":    "up",
+	"\x1b[B":    "down",
+	"\x1bOB":    "down",
+	"\x1b[C":    "right",
+	"\x1bOC":    "right",
+	"\x1b[D":    "left",
+	"\x1bOD":    "left",
+	"\x1b[H":    "home",
+	"\x1bOH":    "home",
+	"\x1b[1~":   "home",
+	"\x1b[F":    "end",
+	"\x1bOF":    "end",
+	"\x1b[4~":   "end",
+	"\x1b[2~":   "insert",
+	"\x1b[3~":   "del",
+	"\x1b[5~":   "pgup",
+	"\x1b[6~":   "pgdn",
+	"\x1b[Z":    "btab",
+	"\x1b[1;3A": "alt-up",
+	"\x1b[1;3B": "alt-down",
+	"\x1bOP":    "f1",
+	"\x1bOQ":    "f2",
+	"\x1bOR":    "f3",
+	"\x1bOS":    "f4",
+	"\x1b[15~":  "f5",
+	"\x1b[17~":  "f6",
+	"\x1b[18~":  "f7",
+	"\x1b[19~":  "f8",
+	"\x1b[20~":  "f9",
+	"\x1b[21~":  "f10",
+	"\x1b[23~":  "f11",
+	"\x1b[24~":  "f12",
+}
+
+// controlKeys names the control characters that have no ctrl-<letter> name.
+var controlKeys = map[byte]string{
+	0:   "ctrl-space",
+	9:   "tab",
+	10:  "enter",
+	13:  "enter",
+	27:  "esc",
+	28:  "ctrl-\\",
+	29:  "ctrl-]",
+	30:  "ctrl-^",
+	31:  "ctrl-/",
+	127: "bspace",
+}
+
+// parseKeys splits a chunk read from the tty into key names. Printable
+// characters are returned as themselves, mouse reports as their escape
+// sequence; unknown escape sequences are returned as empty names.
+func parseKeys(data []byte) []string {
+	var keys []string
+	for len(data) > 0 {
+		name, n := nextKey(data)
+		keys = append(keys, name)
+		data = data[n:]
+	}
+	return keys
+}
+
+// nextKey decodes the first key in data and returns its name along with the
+// number of bytes it occupies.
+func nextKey(data []byte) (string, int) {
+	b := data[0]
+	if b != 27 {
+		if name, ok := controlKeys[b]; ok {
+			return name, 1
+		}
+		if b < 27 {
+			return "ctrl-" + string(rune('a'+b-1)), 1
+		}
+		if b >= utf8.RuneSelf {
+			// A multi-byte UTF-8 character is a single key; invalid
+			// bytes are dropped
+			r, size := utf8.DecodeRune(data)
+			if r == utf8.RuneError {
+				return "", size
+			}
+			return string(data[:size]), size
+		}
+		return string(b), 1
+	}
+
+	if len(data) == 1 || data[1] == 27 {
+		return "esc", 1
+	}
+
+	if data[1] == 'O' && len(data) > 2 {
+		seq := string(data[:3])
+		return escapeSequences[seq], 3
+	}
+
+	if data[1] == '[' && len(data) > 2 {
+		// CSI: parameter and intermediate bytes followed by a final byte
+		end := 2
+		for end < len(data) && (data[end] < 0x40 || data[end] > 0x7e) {
+			end++
+		}
+		if end == len(data) {
+			return "", len(data)
+		}
+		seq := string(data[:end+1])
+		if _, ok := parseMouse(seq); ok {
+			// Mouse reports are passed on as they are, for handleMouse
+			return seq, end + 1
+		}
+		return escapeSequences[seq], end + 1
+	}
+
+	name, n := nextKey(data[1:])
+	if name == "" {
+		return "", n + 1
+	}
+	return "alt-" + name, n + 1
+}
+
+// isPrintableKey reports whether key is a single printable character, which
+// is typed into the filter unless it is bound.
+func isPrintableKey(key string) bool {
+	r, size := utf8.DecodeRuneInString(key)
+	return size == len(key) && r != utf8.RuneError && unicode.IsPrint(r)
+}
+
+// isKeyName reports whether name is a key that can be used with --bind.
+func isKeyName(name string) bool {
+	if isPrintableKey(name) {
+		return true
+	}
+	if len(name) > 4 && name[:4] == "alt-" {
+		return isKeyName(name[4:])
+	}
+	if len(name) == 6 && name[:5] == "ctrl-" && name[5] >= 'a' && name[5] <= 'z' {
+		return true
+	}
+	for _, known := range escapeSequences {
+		if name == known {
+			return true
+		}
+	}
+	for _, known := range controlKeys {
+		if name == known {
+			return true
+		}
+	}
+	for _, known := range mouseKeys {
+		if name == known {
+			return true
+		}
+	}
+	return false
+}
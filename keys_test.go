@@ -0,0 +1,103 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNextKey(t *testing.T) {
+	tests := []struct {
+		data string
+		name string
+		n    int
+	}{
+		{"a", "a", 1},
+		{"ab", "a", 1},
+		{"é!", "é", 2},
+		{"\xff", "", 1},
+		{"\x00", "ctrl-space", 1},
+		{"\x01", "ctrl-a", 1},
+		{"\t", "tab", 1},
+		{"\r", "enter", 1},
+		{"\x7f", "bspace", 1},
+		{"\x1b", "esc", 1},
+		{"\x1b\x1b[A", "esc", 1},
+		{"\x1b[A", "up", 3},
+		{"\x1bOB", "down", 3},
+		{"\x1bOP", "f1", 3},
+		{"\x1b[15~x", "f5", 5},
+		{"\x1b[1;3A", "alt-up", 6},
+		{"\x1b[99~", "", 5},
+		{"\x1b[12", "", 4},
+		{"\x1b[<0;12;5M", "\x1b[<0;12;5M", 10},
+		{"\x1ba", "alt-a", 2},
+		{"\x1b\x01", "alt-ctrl-a", 2},
+		{"\x1b\xff", "", 2},
+	}
+	for _, tt := range tests {
+		name, n := nextKey([]byte(tt.data))
+		if name != tt.name || n != tt.n {
+			t.Errorf("nextKey(%q) = %q, %d; want %q, %d", tt.data, name, n, tt.name, tt.n)
+		}
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		data string
+		want []string
+	}{
+		{"abc", []string{"a", "b", "c"}},
+		{"a\x1b[Bb", []string{"a", "down", "b"}},
+		{"\x1b\x1b[B", []string{"esc", "down"}},
+		{"x\r", []string{"x", "enter"}},
+	}
+	for _, tt := range tests {
+		if got := parseKeys([]byte(tt.data)); !slices.Equal(got, tt.want) {
+			t.Errorf("parseKeys(%q) = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+}
+
+func FuzzParseKeys(f *testing.F) {
+	for _, seed := range []string{
+		"a", "é", "\x00", "\x1b", "\x1b\x1b", "\x1b[A", "\x1bOP", "\x1b[15~",
+		"\x1b[1;3A", "\x1b[<0;12;5M", "\x1b[<64;1;1m", "\x1ba", "\x1b\x1b[B",
+		"\x1b[", "\x1bO", "\x1b[123", "\xff\xfe", "\xe2\x82", "abc\x1b[Bdef",
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		consumed := 0
+		for consumed < len(data) {
+			_, n := nextKey(data[consumed:])
+			if n < 1 || n > len(data)-consumed {
+				t.Fatalf("nextKey(%q) consumed %d bytes", data[consumed:], n)
+			}
+			consumed += n
+		}
+		if keys := parseKeys(data); len(keys) > len(data) {
+			t.Fatalf("parseKeys(%q) returned %d keys", data, len(keys))
+		}
+	})
+}
@@ -24,13 +24,26 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
+	"regexp"
+	"regexp/syntax"
+	"runtime"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
+	"github.com/itchyny/gojq"
+	"github.com/tidwall/gjson"
 	"golang.org/x/term"
 )
 
@@ -41,15 +54,27 @@ const (
 	hideCursor    = "\033[?25l"
 	showCursor    = "\033[?25h"
 	clearLine     = "\033[2K"
+	clearToEOL    = "\033[K"
+	clearToEOS    = "\033[J"
+	saveCursor    = "\0337"
+	restoreCursor = "\0338"
 	colorReset    = "\033[0m"
 	colorReverse  = "\033[7m"
-	colorCyan     = "\033[36m"
 	colorGreen    = "\033[32m"
-	colorSelected = "\033[42m" // Green background for selected
 	altScreenOn   = "\033[?1049h"
 	altScreenOff  = "\033[?1049l"
 )
 
+// Colors of the parts of the screen, which the colors of the config file
+// override
+var (
+	colorInfo     = "\033[36m"   // Labels, counters and query operators
+	colorError    = "\033[31m"   // Errors and messages
+	colorSelected = "\033[42m"   // Green background for selected
+	colorMatch    = "\033[1;33m" // Parts of the items matching the filter
+	colorHeader   = "\033[1;4m"  // Bold and underlined column names
+)
+
 func setRawMode(fd uintptr) (*term.State, error) {
 	oldState, err := term.MakeRaw(int(fd))
 	if err != nil {
@@ -65,14 +90,14 @@ func restoreTerminal(fd uintptr, oldState *term.State) {
 	_ = term.Restore(int(fd), oldState)
 }
 
+// getTerminalSize returns the size of the terminal tty draws on; on Windows
+// it must be the console screen buffer. When the size cannot be read, as
+// with a dumb terminal, it assumes 80x24.
 func getTerminalSize(tty *os.File) (width, height int, err error) {
-	cmd := exec.Command("stty", "size")
-	cmd.Stdin = tty
-	out, err := cmd.Output()
-	if err != nil {
+	width, height, err = term.GetSize(int(tty.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
 		return 80, 24, nil // default values
 	}
-	fmt.Sscanf(string(out), "%d %d", &height, &width)
 	return width, height, nil
 }
 
@@ -86,11 +111,90 @@ type App struct {
 	width        int
 	height       int
 	tty          *os.File
+	ttyOut       *os.File
+	out          io.Writer
 	truncate     bool
 	tableMode    bool
-	selected     map[int]bool
+	selected     map[int]int // selected items, numbered in the order they were selected
+	selections   int         // number given to the last selected item
 	separator    string
 	colWidths    []int
+	colLimits    map[string]int // widths columns are cut at, by display attribute
+	key          string
+	load         func() ([]map[string]interface{}, []itemPath, error)
+	message      string
+	control      net.Listener
+	controlPath  string
+	commands     chan controlCommand
+	attrs        *attrIndex    // attributes of the items, built when first needed
+	jobs         chan error    // failures of the commands run by execute-silent
+	quit         chan struct{} // closed when the picker exits
+	bindings     map[string]action
+	termState    *term.State
+	hscroll      int
+	linkField    string
+	recorder     *sessionRecorder
+	replay       []sessionEvent
+	columns      []computedColumn
+	jqExprs      map[string]*gojq.Code
+	jqMode       bool
+	jqCode       *gojq.Code
+	savedFilter  string
+	drill        []drillLevel
+	treeMode     bool
+	treeOpen     map[string]bool // jq paths of the expanded nodes in --tree mode
+	facets       *facetBar
+	facetAttr    string
+	groupBy      string
+	unique       *uniqueFilter
+	groupCounts  map[string]int  // number of filtered items in each group
+	collapsed    map[string]bool // groups showing only their header
+	sortColumn   int
+	sortDesc     bool
+	sortBy       string
+	sortRows     bool
+	tac          bool
+	watch        time.Duration
+	showPreview  bool
+	popup        *valuePopup
+	filterStack  []string
+	baseItems    []int
+	keepOutput   bool
+	info         string
+	colorOutput  bool
+	merge        bool
+	paths        []itemPath
+	printJQPath  bool
+	execCmd      string
+	loop         bool
+	pretty       bool
+	bidi         bool
+	multi        bool
+	previewSide  string
+	inlineHeight string
+	stream       *inputStream
+	indexFile    *os.File // input file read again for whole objects with --index
+	prepare      func(objects []map[string]interface{}, raws []json.RawMessage, first int) ([]map[string]interface{}, []itemPath)
+	allAttrs     bool
+	defaultIndex int
+	defaultMatch string
+	preselectBy  string
+	preselected  []string
+	outputAttrs  []string
+	delimiter    string
+	format       *template.Template
+	regex        bool
+	caseMode     caseMode
+	literal      bool // whether queries match accented letters exactly
+	filterErr    string
+	searchAll    bool
+	mouse        bool
+	listTop      int   // screen row of the first item
+	itemRows     []int // item drawn on each row from listTop
+	lastClick    time.Time
+	clickedItem  int
+	normalKeys   map[string]action
+	normalMode   bool
 }
 
 func newApp(objects []map[string]interface{}, displayAttrs []string, outputAttr string, tty *os.File, truncate bool, tableMode bool, separator string) *App {
@@ -110,10 +214,18 @@ func newApp(objects []map[string]interface{}, displayAttrs []string, outputAttr
 		width:        width,
 		height:       height,
 		tty:          tty,
+		ttyOut:       tty,
+		out:          tty,
 		truncate:     truncate,
 		tableMode:    tableMode,
-		selected:     make(map[int]bool),
+		selected:     make(map[int]int),
 		separator:    separator,
+		bindings:     defaultBindings(),
+		jobs:         make(chan error),
+		quit:         make(chan struct{}),
+		sortColumn:   -1,
+		info:         "default",
+		defaultIndex: -1,
 	}
 
 	if tableMode && len(displayAttrs) > 0 {
@@ -123,38 +235,217 @@ func newApp(objects []map[string]interface{}, displayAttrs []string, outputAttr
 	return app
 }
 
+// attrValue looks up attr in obj, evaluating it when it names a computed
+// column or is a jq expression.
+func (a *App) attrValue(obj map[string]interface{}, attr string) (interface{}, bool) {
+	if isJQExpr(attr) {
+		return a.evalJQ(obj, attr)
+	}
+	for _, col := range a.columns {
+		if col.name == attr {
+			v, ok := col.eval(obj)
+			if !ok {
+				return nil, false
+			}
+			return v, true
+		}
+	}
+	return lookupAttr(obj, attr)
+}
+
+// lookupAttr looks up attr in obj. An attribute that is not a key of obj is
+// taken as a dot separated path through nested objects and arrays, e.g.
+// metadata.name or spec.containers.0.image, or as an RFC 6901 JSON Pointer
+// when it starts with a slash, e.g. /metadata/labels/app.kubernetes.io~1name.
+// Paths using gjson syntax, such as friends.#.first, are resolved by gjson.
+// An item made from an array element that isn't an object only has value.
+func lookupAttr(obj map[string]interface{}, attr string) (interface{}, bool) {
+	if v, ok := elementValue(obj); ok {
+		return v, attr == "value"
+	}
+	if val, ok := obj[attr]; ok {
+		return val, true
+	}
+	if isGJSONPath(attr) {
+		return lookupGJSON(obj, attr)
+	}
+	if !isAttrPath(attr) {
+		return nil, false
+	}
+
+	var cur interface{} = obj
+	for _, part := range attrPath(attr) {
+		switch v := cur.(type) {
+		case map[string]interface{}:
+			val, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			cur = val
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(v) || !isPointerIndex(attr, part) {
+				return nil, false
+			}
+			cur = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// isAttrPath tells whether attr, when it is not a key, is a path: one with
+// dots, or a JSON Pointer.
+func isAttrPath(attr string) bool {
+	return strings.Contains(attr, ".") || strings.HasPrefix(attr, "/")
+}
+
+// attrPath splits the path attr into the keys and array indices it goes
+// through. JSON Pointers are split on slashes, with ~1 and ~0 standing for
+// / and ~ in keys.
+func attrPath(attr string) []string {
+	if !strings.HasPrefix(attr, "/") {
+		return strings.Split(attr, ".")
+	}
+	parts := strings.Split(attr[1:], "/")
+	for i, part := range parts {
+		parts[i] = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+	}
+	return parts
+}
+
+// isGJSONPath tells whether attr uses the gjson path syntax beyond keys and
+// indices: # for arrays, * and ? wildcards, | and @ modifiers, and \ to
+// escape dots.
+func isGJSONPath(attr string) bool {
+	return !strings.HasPrefix(attr, "/") && strings.ContainsAny(attr, "#*?|@\\")
+}
+
+// lookupGJSON looks up the gjson path attr in obj.
+func lookupGJSON(obj map[string]interface{}, attr string) (interface{}, bool) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, false
+	}
+	result := gjson.GetBytes(data, attr)
+	if !result.Exists() {
+		return nil, false
+	}
+	val, err := decodeJQValue([]byte(result.Raw))
+	if err != nil {
+		return nil, false
+	}
+	return val, true
+}
+
+// isPointerIndex tells whether part may index an array in the path attr.
+// JSON Pointers only allow array indices without signs or leading zeros.
+func isPointerIndex(attr, part string) bool {
+	if !strings.HasPrefix(attr, "/") {
+		return true
+	}
+	if part == "0" {
+		return true
+	}
+	return part != "" && part[0] >= '1' && part[0] <= '9' && strings.Trim(part, "0123456789") == ""
+}
+
 func (a *App) calculateColumnWidths() {
 	a.colWidths = make([]int, len(a.displayAttrs))
 
-	// Calculate max width for each column
+	// Calculate max width for each column, in terminal cells, starting
+	// with the width of its name in the header and the sort arrow
+	for i, name := range a.fitColumns(slices.Clone(a.displayAttrs)) {
+		a.colWidths[i] = displayWidth(name) + 2
+	}
 	for _, obj := range a.objects {
-		for i, attr := range a.displayAttrs {
-			if val, ok := obj[attr]; ok {
-				valStr := fmt.Sprintf("%v", val)
-				if len(valStr) > a.colWidths[i] {
-					a.colWidths[i] = len(valStr)
+		for i, valStr := range a.fitColumns(a.displayValues(obj)) {
+			a.colWidths[i] = max(a.colWidths[i], displayWidth(valStr))
+		}
+	}
+}
+
+// defaultColumnWidth is the width a column of --columns is cut at when it
+// doesn't set one.
+const defaultColumnWidth = 40
+
+// parseColumnList parses the display attributes of --columns, separated by
+// commas, each optionally followed by :width, the number of cells its
+// values are cut at.
+func parseColumnList(spec string) ([]string, map[string]int, error) {
+	parts := []string{spec}
+	if !isJQExpr(spec) {
+		parts = strings.Split(spec, ",")
+	}
+	var attrs []string
+	widths := make(map[string]int)
+	for _, part := range parts {
+		attr, width := part, defaultColumnWidth
+		if i := strings.LastIndex(part, ":"); i >= 0 {
+			if n, err := strconv.Atoi(part[i+1:]); err == nil {
+				if n < 4 {
+					return nil, nil, fmt.Errorf("--columns: the width of %s must be at least 4", part[:i])
 				}
+				attr, width = part[:i], n
 			}
 		}
+		if attr == "" {
+			return nil, nil, fmt.Errorf("--columns expects attributes separated by commas, e.g. name,status:10")
+		}
+		attrs = append(attrs, attr)
+		widths[attr] = width
+	}
+	return attrs, widths, nil
+}
+
+// fitColumns cuts the display values of an item that are wider than their
+// column allows with --columns.
+func (a *App) fitColumns(values []string) []string {
+	if len(a.colLimits) == 0 || a.jqMode || a.treeMode || len(values) != len(a.displayAttrs) {
+		return values
+	}
+	for i, valStr := range values {
+		if limit := a.colLimits[a.displayAttrs[i]]; limit > 0 {
+			values[i] = truncateWidth(valStr, limit)
+		}
 	}
+	return values
 }
 
 func (a *App) updateFilter() {
-	filterText := strings.ToLower(a.filter)
-	if filterText == "" {
-		a.filtered = make([]int, len(a.objects))
+	candidates := a.baseItems
+	if candidates == nil {
+		candidates = make([]int, len(a.objects))
 		for i := range a.objects {
-			a.filtered[i] = i
+			candidates[i] = i
 		}
-		return
 	}
 
-	a.filtered = []int{}
-	for i, obj := range a.objects {
-		displayVal := a.getDisplayValue(obj)
-		if strings.Contains(strings.ToLower(displayVal), filterText) {
-			a.filtered = append(a.filtered, i)
-		}
+	if a.treeMode && !a.treeFiltered() {
+		candidates = a.visibleNodes(candidates)
+	}
+
+	var filtered []int
+	var err error
+	if a.jqMode {
+		filtered, err = a.matchJQ(candidates, a.filter)
+	} else {
+		filtered, err = a.matchItems(candidates, a.filter)
+	}
+	if err != nil {
+		// Keep the last results while the pattern is being typed
+		a.filterErr = err.Error()
+		return
+	}
+	if a.facets != nil {
+		filtered = a.narrowFacets(filtered)
+	}
+	a.filterErr = ""
+	a.filtered = filtered
+	a.sortFiltered()
+	if a.groupBy != "" {
+		a.groupFiltered()
 	}
 
 	// Adjust cursor if needed
@@ -163,57 +454,365 @@ func (a *App) updateFilter() {
 	}
 }
 
+// matchItems returns the candidates matching the terms of query, against
+// their display value (or whole object, with search-all) and the
+// attributes named by its field:value terms. It fails when query is not a
+// valid pattern in regex mode.
+func (a *App) matchItems(candidates []int, query string) ([]int, error) {
+	if query == "" {
+		return append([]int{}, candidates...), nil
+	}
+
+	matcher, err := a.compileItemQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []int{}
+	for _, i := range candidates {
+		if matcher(a.objects[i]) {
+			matches = append(matches, i)
+		}
+	}
+	return matches, nil
+}
+
+// compileQuery returns a function reporting whether a display value
+// matches query as a whole: as a substring, or as a regular expression in
+// regex mode, ignoring case as ignoreCase tells.
+func (a *App) compileQuery(query string) (func(string) bool, error) {
+	if a.regex {
+		if a.regexIgnoresCase(query) {
+			query = "(?i)" + query
+		}
+		re, err := regexp.Compile(query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex: %s", regexErrorCode(err))
+		}
+		return re.MatchString, nil
+	}
+
+	fold := a.ignoreCase(query)
+	filterText := a.foldText(query, fold)
+	return func(value string) bool {
+		return strings.Contains(a.foldText(value, fold), filterText)
+	}, nil
+}
+
+// regexErrorCode shortens a regexp syntax error to its description, which
+// fits next to the filter better than the full message repeating the
+// pattern.
+func regexErrorCode(err error) string {
+	var syntaxErr *syntax.Error
+	if errors.As(err, &syntaxErr) {
+		return string(syntaxErr.Code)
+	}
+	return err.Error()
+}
+
+// searchText is the text of obj that queries are matched against.
+func (a *App) searchText(obj map[string]interface{}) string {
+	if a.searchAll {
+		jsonBytes, _ := objectJSON(obj, nil)
+		return string(jsonBytes)
+	}
+	// Values cut to fit their column are matched in full
+	return a.joinDisplayValues(a.displayValues(obj))
+}
+
+// toggleSearchAll switches between matching queries against the display
+// value and against the whole object.
+func (a *App) toggleSearchAll() {
+	a.searchAll = !a.searchAll
+	a.computeBaseItems()
+	a.updateFilter()
+}
+
+// toggleRegex switches between substring and regular expression matching.
+func (a *App) toggleRegex() {
+	a.regex = !a.regex
+	a.computeBaseItems()
+	a.updateFilter()
+}
+
+// pushFilter freezes the current results and starts a fresh query that
+// narrows them down further.
+func (a *App) pushFilter() {
+	if a.filterErr != "" || a.jqMode {
+		return
+	}
+	a.filterStack = append(a.filterStack, a.filter)
+	a.filter = ""
+	a.computeBaseItems()
+	a.updateFilter()
+}
+
+// popFilter drops the current query and goes back to editing the previous
+// one.
+func (a *App) popFilter() {
+	if len(a.filterStack) == 0 {
+		return
+	}
+	last := len(a.filterStack) - 1
+	a.filter = a.filterStack[last]
+	a.filterStack = a.filterStack[:last]
+	a.computeBaseItems()
+	a.updateFilter()
+}
+
+// computeBaseItems applies the frozen queries to all objects, giving the
+// items the current query filters. It is nil when nothing is frozen.
+func (a *App) computeBaseItems() {
+	a.baseItems = nil
+	if len(a.filterStack) == 0 {
+		return
+	}
+
+	items := make([]int, len(a.objects))
+	for i := range a.objects {
+		items[i] = i
+	}
+	for _, query := range a.filterStack {
+		// A frozen query that is not a valid pattern since regex mode
+		// was toggled leaves the items as they are
+		if matches, err := a.matchItems(items, query); err == nil {
+			items = matches
+		}
+	}
+	a.baseItems = items
+}
+
+// sortKey returns the attribute items are sorted by: the sort column, or
+// the --sort attribute until a sort column is picked. It is empty for input
+// order.
+func (a *App) sortKey() string {
+	if a.sortColumn >= 0 && a.sortColumn < len(a.displayAttrs) {
+		return a.displayAttrs[a.sortColumn]
+	}
+	return a.sortBy
+}
+
+// sortFiltered orders the filtered items by the sort key, if any. Items
+// comparing equal keep their input order, which is reversed with --tac.
+func (a *App) sortFiltered() {
+	if a.tac {
+		slices.Reverse(a.filtered)
+	}
+	if a.sortRows {
+		rows := make(map[int]string, len(a.filtered))
+		for _, idx := range a.filtered {
+			rows[idx] = a.getDisplayValue(a.objects[idx])
+		}
+		sort.SliceStable(a.filtered, func(i, j int) bool {
+			x, y := rows[a.filtered[i]], rows[a.filtered[j]]
+			if a.sortDesc {
+				return compareValues(y, x) < 0
+			}
+			return compareValues(x, y) < 0
+		})
+		return
+	}
+
+	attr := a.sortKey()
+	if attr == "" {
+		return
+	}
+
+	sort.SliceStable(a.filtered, func(i, j int) bool {
+		x, xok := a.attrValue(a.objects[a.filtered[i]], attr)
+		y, yok := a.attrValue(a.objects[a.filtered[j]], attr)
+		// Missing values go last in both directions
+		if !xok || !yok {
+			return xok && !yok
+		}
+		if a.sortDesc {
+			return compareValues(y, x) < 0
+		}
+		return compareValues(x, y) < 0
+	})
+}
+
+// compareValues orders two attribute values, numerically when both are
+// numbers or numeric strings, so that "9" sorts before "10".
+func compareValues(x, y interface{}) int {
+	xn, xok := toNumber(x)
+	yn, yok := toNumber(y)
+	switch {
+	case xok && yok:
+		if xn < yn {
+			return -1
+		} else if xn > yn {
+			return 1
+		}
+		return 0
+	case xok:
+		return -1
+	case yok:
+		return 1
+	}
+	return strings.Compare(fmt.Sprintf("%v", x), fmt.Sprintf("%v", y))
+}
+
+// cycleSortColumn moves sorting to the next display attribute, or to the
+// previous one when step is negative, going through input order past the
+// last and the first one. The cursor stays on the same item.
+func (a *App) cycleSortColumn(step int) {
+	n := len(a.displayAttrs)
+	if n == 0 {
+		return
+	}
+	a.sortColumn = (a.sortColumn+1+step%(n+1)+n+1)%(n+1) - 1
+	a.sortBy = ""
+	a.sortRows = false
+	a.resort()
+}
+
+// sortByColumn sorts by the display attribute of column i, as when its name
+// is clicked in the header, or reverses the order when it is already
+// sorted by it.
+func (a *App) sortByColumn(i int) {
+	if a.sortColumn == i && a.sortBy == "" && !a.sortRows {
+		a.toggleSortDirection()
+		return
+	}
+	a.sortColumn, a.sortDesc = i, false
+	a.sortBy = ""
+	a.sortRows = false
+	a.resort()
+}
+
+// cycleSortRows cycles between input order and sorting by the displayed
+// row, ascending then descending.
+func (a *App) cycleSortRows() {
+	switch {
+	case !a.sortRows:
+		a.sortRows, a.sortDesc = true, false
+	case !a.sortDesc:
+		a.sortDesc = true
+	default:
+		a.sortRows, a.sortDesc = false, false
+	}
+	a.sortColumn, a.sortBy = -1, ""
+	a.resort()
+}
+
+func (a *App) toggleSortDirection() {
+	a.sortDesc = !a.sortDesc
+	a.resort()
+}
+
+// resort re-applies the filter and sort order, keeping the cursor on the
+// item it was on.
+func (a *App) resort() {
+	current := -1
+	if a.cursor < len(a.filtered) {
+		current = a.filtered[a.cursor]
+	}
+	a.updateFilter()
+	for i, idx := range a.filtered {
+		if idx == current {
+			a.cursor = i
+			break
+		}
+	}
+}
+
 func (a *App) getDisplayValue(obj map[string]interface{}) string {
+	return a.joinDisplayValues(a.fitColumns(a.displayValues(obj)))
+}
+
+// displayValues returns the display attribute values of obj, or obj as JSON
+// on one line when there are no display attributes. An array element that
+// isn't an object shows its value, unless value is a display attribute.
+func (a *App) displayValues(obj map[string]interface{}) []string {
+	if a.jqMode && a.jqCode != nil {
+		row, _ := a.jqRow(obj)
+		return []string{row}
+	}
+	if a.treeMode {
+		return []string{a.treeRow(obj)}
+	}
+	if _, ok := elementValue(obj); ok && !slices.Contains(a.displayAttrs, "value") {
+		return []string{elementText(obj)}
+	}
 	if len(a.displayAttrs) == 0 {
 		// Display entire object as JSON on one line
-		jsonBytes, err := json.Marshal(obj)
+		jsonBytes, err := objectJSON(obj, nil)
 		if err == nil {
-			return string(jsonBytes)
+			return []string{string(jsonBytes)}
 		}
-		return ""
+		return []string{""}
 	}
 
 	// Get values for each display attribute
 	values := []string{}
-	for i, attr := range a.displayAttrs {
+	for _, attr := range a.displayAttrs {
 		var valStr string
-		if val, ok := obj[attr]; ok {
-			// Check if value is an object or array, serialize to JSON
-			switch v := val.(type) {
-			case map[string]interface{}, []interface{}:
-				jsonBytes, err := json.Marshal(v)
-				if err == nil {
-					valStr = string(jsonBytes)
-				} else {
-					valStr = fmt.Sprintf("%v", val)
-				}
-			default:
-				valStr = fmt.Sprintf("%v", val)
-			}
-		}
-
-		if a.tableMode && i < len(a.colWidths) {
-			// Pad value to column width
-			if i < len(a.displayAttrs)-1 {
-				// Not the last column, pad to width
-				valStr = fmt.Sprintf("%-*s", a.colWidths[i], valStr)
+		if val, ok := a.attrValue(obj, attr); ok {
+			// Shown as printed with -o, so that the filter matches what
+			// is output; objects and arrays are serialized to JSON
+			formatted, err := formatOutputValue(val)
+			if err != nil {
+				formatted = fmt.Sprintf("%v", val)
 			}
-			// Last column doesn't need padding
+			valStr = formatted
 		}
-
 		values = append(values, valStr)
 	}
+	return values
+}
 
-	if len(values) == 0 {
-		return ""
+// joinDisplayValues joins the values returned by displayValues into the row
+// shown for an item, padding them into columns in table mode.
+func (a *App) joinDisplayValues(values []string) string {
+	if len(a.displayAttrs) == 0 {
+		return values[0]
 	}
 
 	if a.tableMode {
-		return strings.Join(values, "  ")
+		padded := make([]string, len(values))
+		for i, valStr := range values {
+			// Pad value to column width, except for the last column
+			if i < len(a.colWidths) && i < len(values)-1 {
+				valStr = padRight(valStr, a.colWidths[i])
+			}
+			padded[i] = valStr
+		}
+		return strings.Join(padded, "  ")
 	}
 	return strings.Join(values, a.separator)
 }
 
+// visualDisplayValue returns the row shown for obj with right-to-left text
+// in visual order. Every value is reordered on its own, so that columns keep
+// their order. When limit is positive, the row is cut at that width, and the
+// value crossing it is cut at its logical end before being reordered, so
+// that right-to-left text loses its end rather than its beginning.
+func (a *App) visualDisplayValue(obj map[string]interface{}, limit int) string {
+	values := a.fitColumns(a.displayValues(obj))
+	sepWidth := displayWidth(a.separator)
+	if a.tableMode {
+		sepWidth = 2
+	}
+
+	col := 0
+	for i, valStr := range values {
+		if limit > 0 && col+displayWidth(valStr) > limit {
+			values[i] = visualOrder(truncateWidth(valStr, max(limit-col, 3)))
+			values = values[:i+1]
+			break
+		}
+		values[i] = visualOrder(valStr)
+
+		valWidth := displayWidth(valStr)
+		if a.tableMode && i < len(a.colWidths) {
+			valWidth = max(valWidth, a.colWidths[i])
+		}
+		col += valWidth + sepWidth
+	}
+	return a.joinDisplayValues(values)
+}
+
 func (a *App) calculateLines(displayVal string) int {
 	if displayVal == "" {
 		return 1
@@ -226,7 +825,7 @@ func (a *App) calculateLines(displayVal string) int {
 	if effectiveWidth <= 0 {
 		return 1
 	}
-	lines := (len(displayVal) + effectiveWidth - 1) / effectiveWidth
+	lines := (displayWidth(displayVal) + effectiveWidth - 1) / effectiveWidth
 	if lines == 0 {
 		return 1
 	}
@@ -237,24 +836,154 @@ func (a *App) getMaxDisplayWidth() int {
 	maxWidth := 0
 	for _, idx := range a.filtered {
 		obj := a.objects[idx]
-		displayVal := a.getDisplayValue(obj)
-		if len(displayVal) > maxWidth {
-			maxWidth = len(displayVal)
-		}
+		maxWidth = max(maxWidth, displayWidth(a.getDisplayValue(obj)))
 	}
 	return maxWidth
 }
 
+// linkURL returns the URL associated with obj: the value of the link field
+// when one is set, otherwise the first displayed value that looks like a URL.
+func (a *App) linkURL(obj map[string]interface{}) string {
+	attrs := a.displayAttrs
+	if a.linkField != "" {
+		attrs = []string{a.linkField}
+	}
+
+	for _, attr := range attrs {
+		val, _ := a.attrValue(obj, attr)
+		if url, ok := val.(string); ok && isURL(url) {
+			return url
+		}
+	}
+	return ""
+}
+
+func isURL(s string) bool {
+	if !strings.HasPrefix(s, "http://") && !strings.HasPrefix(s, "https://") {
+		return false
+	}
+	// Control characters would terminate the escape sequence early
+	return !strings.ContainsFunc(s, func(r rune) bool { return r < 32 || r == 127 })
+}
+
+// hyperlink wraps text in an OSC 8 escape sequence pointing at url.
+// Terminals without support for it show the text unchanged.
+func hyperlink(url, text string) string {
+	return "\033]8;;" + url + "\033\\" + text + "\033]8;;\033\\"
+}
+
+// openLink opens the URL of the item under the cursor in the default
+// browser.
+func (a *App) openLink() {
+	if len(a.filtered) == 0 || a.cursor >= len(a.filtered) {
+		return
+	}
+	url := a.linkURL(a.object(a.filtered[a.cursor]))
+	if url == "" {
+		return
+	}
+
+	cmd := exec.Command("xdg-open", url)
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	}
+	if err := cmd.Start(); err != nil {
+		a.message = fmt.Sprintf("opening link failed: %v", err)
+		return
+	}
+	go cmd.Wait()
+}
+
+// render draws the whole screen. The frame is assembled in memory and
+// written to the tty in a single call, which avoids a syscall per line on
+// slow connections.
 func (a *App) render() {
-	fmt.Fprint(a.tty, clearScreen+cursorHome)
+	var frame bytes.Buffer
+	if a.inlineHeight == "" {
+		fmt.Fprint(&frame, clearScreen+cursorHome)
+	} else {
+		fmt.Fprint(&frame, restoreCursor+clearToEOS)
+	}
+	a.drawFrame(&frame)
+	a.out.Write(frame.Bytes())
+}
 
+// drawFrame draws the filter line, the visible items and the preview pane
+// into frame.
+func (a *App) drawFrame(frame *bytes.Buffer) {
 	// Display filter
-	fmt.Fprintf(a.tty, "%sFilter:%s %s\r\n", colorCyan, colorReset, a.filter)
+	label := "Filter:"
+	if a.jqMode {
+		label = "jq:"
+	}
+	fmt.Fprintf(frame, "%s%s%s ", colorInfo, label, colorReset)
+	for _, query := range a.filterStack {
+		fmt.Fprintf(frame, "%s %s›%s ", query, colorInfo, colorReset)
+	}
+	if a.jqMode || a.regex {
+		fmt.Fprint(frame, a.filter)
+	} else {
+		query, problem := a.colorQuery(a.filter)
+		fmt.Fprint(frame, query)
+		if problem != "" {
+			fmt.Fprintf(frame, "  %s⚠ %s%s", colorError, problem, colorReset)
+		}
+	}
+	if a.filterErr != "" {
+		fmt.Fprintf(frame, "  %s⚠ %s%s", colorError, a.filterErr, colorReset)
+	}
+	if a.info == "inline" {
+		fmt.Fprintf(frame, "  %s%s%s", colorInfo, a.matchCounter(), colorReset)
+	}
+	if a.info != "status" {
+		if len(a.selected) > 0 {
+			fmt.Fprintf(frame, "  %s[%d selected]%s", colorGreen, len(a.selected), colorReset)
+		}
+		for _, mode := range a.modes() {
+			fmt.Fprintf(frame, "  %s[%s]%s", colorInfo, mode, colorReset)
+		}
+	}
+	if a.message != "" {
+		fmt.Fprintf(frame, "  %s%s%s", colorError, a.message, colorReset)
+	}
+	fmt.Fprint(frame, "\r\n")
+	if a.info == "default" {
+		fmt.Fprintf(frame, "  %s%s%s\r\n", colorInfo, a.matchCounter(), colorReset)
+	}
+	if crumb := a.breadcrumb(); crumb != "" {
+		fmt.Fprintf(frame, "  %s› %s%s\r\n", colorInfo, crumb, colorReset)
+	}
+	if a.headerHeight() > 0 {
+		header := a.headerRow()
+		if a.truncate {
+			header = skipWidth(header, a.hscroll)
+		}
+		if maxWidth := a.listWidth() - 2; maxWidth > 3 {
+			header = truncateWidth(header, maxWidth)
+		}
+		fmt.Fprintf(frame, "  %s%s%s\r\n", colorHeader, header, colorReset)
+	}
+	if a.info == "status" {
+		// Drawn last, past the items and the preview pane
+		defer a.drawStatusLine(frame)
+	}
 
 	// Calculate visible window based on actual line usage
-	availableLines := a.height - 4
-	if availableLines <= 0 {
-		availableLines = 1
+	availableLines := a.listHeight()
+	a.listTop = 2 + a.crumbHeight() + a.headerHeight()
+	if a.info == "default" {
+		a.listTop++
+	}
+	a.itemRows = a.itemRows[:0]
+
+	if a.popup != nil {
+		a.popup.render(frame, availableLines, a.width)
+		a.renderFacets(frame)
+		a.renderPreview(frame)
+		return
 	}
 
 	// Find the range of items to display
@@ -271,7 +1000,7 @@ func (a *App) render() {
 			idx := a.filtered[start-1]
 			obj := a.objects[idx]
 			displayVal := a.getDisplayValue(obj)
-			itemLines := a.calculateLines(displayVal)
+			itemLines := a.itemLines(start-1, displayVal)
 			if usedLines+itemLines > availableLines/2 {
 				break
 			}
@@ -283,7 +1012,7 @@ func (a *App) render() {
 		idx := a.filtered[a.cursor]
 		obj := a.objects[idx]
 		displayVal := a.getDisplayValue(obj)
-		usedLines += a.calculateLines(displayVal)
+		usedLines += a.itemLines(a.cursor, displayVal)
 
 		// Expand downward from cursor
 		end = a.cursor + 1
@@ -291,7 +1020,7 @@ func (a *App) render() {
 			idx := a.filtered[end]
 			obj := a.objects[idx]
 			displayVal := a.getDisplayValue(obj)
-			itemLines := a.calculateLines(displayVal)
+			itemLines := a.itemLines(end, displayVal)
 			if usedLines+itemLines > availableLines {
 				break
 			}
@@ -311,7 +1040,7 @@ func (a *App) render() {
 			idx := a.filtered[i]
 			obj := a.objects[idx]
 			displayVal := a.getDisplayValue(obj)
-			if len(displayVal) > effectiveWidth {
+			if displayWidth(displayVal) > effectiveWidth {
 				hasWrappingLines = true
 				break
 			}
@@ -319,336 +1048,2123 @@ func (a *App) render() {
 	}
 
 	// Display items
+	highlight := a.highlightPattern()
 	for i := start; i < end; i++ {
 		idx := a.filtered[i]
 		obj := a.objects[idx]
+		if a.groupStart(i) {
+			header := a.groupHeader(i)
+			if maxWidth := a.listWidth() - 2; maxWidth > 3 {
+				header = truncateWidth(header, maxWidth)
+			}
+			a.itemRows = append(a.itemRows, i)
+			if i == a.cursor && a.groupCollapsed(i) {
+				fmt.Fprintf(frame, "%s> %s%s\r\n", colorReverse, header, colorReset)
+			} else {
+				fmt.Fprintf(frame, "  %s%s%s\r\n", colorInfo, header, colorReset)
+			}
+		}
+		if a.groupCollapsed(i) {
+			continue
+		}
 		displayVal := a.getDisplayValue(obj)
+		for range a.calculateLines(displayVal) {
+			a.itemRows = append(a.itemRows, i)
+		}
 
-		// Truncate if needed
+		if a.bidi {
+			limit := 0
+			if a.truncate && a.hscroll == 0 {
+				limit = a.listWidth() - 2
+			}
+			displayVal = a.visualDisplayValue(obj, limit)
+		}
+
+		// Scroll horizontally and truncate if needed. Items wider than the
+		// list may be out of view, so the padding stops at its edge.
+		padWidth := min(maxDisplayWidth, a.listWidth()-2)
 		if a.truncate {
-			maxWidth := a.width - 2 // Account for "> " or "  " prefix
-			if len(displayVal) > maxWidth && maxWidth > 3 {
-				displayVal = displayVal[:maxWidth-3] + "..."
+			displayVal = skipWidth(displayVal, a.hscroll)
+			maxWidth := a.listWidth() - 2 // Account for "> " or "  " prefix
+			if maxWidth > 3 {
+				displayVal = truncateWidth(displayVal, maxWidth)
 			}
+			padWidth = min(maxDisplayWidth-a.hscroll, maxWidth)
+		}
+
+		isSelected := a.selected[idx] > 0
+		style := ""
+		if i == a.cursor {
+			style = colorReverse
+		}
+		if isSelected {
+			style += colorSelected
 		}
 
 		// Pad display value for uniform highlighting, but only if:
 		// - truncate mode is enabled, OR
 		// - no lines are wrapping
-		var renderVal string
+		padding := ""
 		if a.truncate || !hasWrappingLines {
-			renderVal = fmt.Sprintf("%-*s", maxDisplayWidth, displayVal)
-		} else {
-			renderVal = displayVal
+			padding = strings.Repeat(" ", max(0, padWidth-displayWidth(displayVal)))
 		}
+		displayVal = highlightMatches(displayVal, highlight, style)
+		renderVal := displayVal + padding
 
-		isSelected := a.selected[idx]
+		if url := a.linkURL(obj); url != "" {
+			renderVal = hyperlink(url, renderVal)
+			displayVal = hyperlink(url, displayVal)
+		}
+
+		prefix := "  "
+		if i == a.cursor {
+			prefix = "> "
+		}
+		if a.multi && isSelected {
+			// Mark selected items next to the cursor column
+			prefix = prefix[:1] + "*"
+		}
 		if i == a.cursor {
 			if isSelected {
-				fmt.Fprintf(a.tty, "%s%s> %s%s\r\n", colorReverse, colorSelected, renderVal, colorReset)
+				fmt.Fprintf(frame, "%s%s%s%s%s\r\n", colorReverse, colorSelected, prefix, renderVal, colorReset)
 			} else {
-				fmt.Fprintf(a.tty, "%s> %s%s\r\n", colorReverse, renderVal, colorReset)
+				fmt.Fprintf(frame, "%s%s%s%s\r\n", colorReverse, prefix, renderVal, colorReset)
 			}
 		} else {
 			if isSelected {
-				fmt.Fprintf(a.tty, "%s  %s%s\r\n", colorSelected, renderVal, colorReset)
+				fmt.Fprintf(frame, "%s%s%s%s\r\n", colorSelected, prefix, renderVal, colorReset)
 			} else {
-				fmt.Fprintf(a.tty, "  %s\r\n", displayVal)
+				fmt.Fprintf(frame, "%s%s\r\n", prefix, displayVal)
 			}
 		}
 	}
 
 	if len(a.filtered) == 0 {
-		fmt.Fprint(a.tty, "  (no matches)\r\n")
+		fmt.Fprint(frame, "  (no matches)\r\n")
 	}
+
+	a.renderFacets(frame)
+	a.renderPreview(frame)
 }
 
-func (a *App) toggleSelection() {
-	if len(a.filtered) > 0 && a.cursor < len(a.filtered) {
-		idx := a.filtered[a.cursor]
-		a.selected[idx] = !a.selected[idx]
-		if a.cursor < len(a.filtered)-1 {
-			a.cursor++
-		}
+// listHeight returns the number of lines available to the list.
+func (a *App) listHeight() int {
+	return max(1, a.height-4-a.infoHeight()-a.crumbHeight()-a.headerHeight()-a.previewHeight())
+}
+
+// headerHeight returns the number of lines taken by the names of the
+// columns, pinned above the list in table mode.
+func (a *App) headerHeight() int {
+	if a.tableMode && len(a.displayAttrs) > 0 && !a.jqMode {
+		return 1
 	}
+	return 0
 }
 
-func (a *App) getSelection() []int {
-	if len(a.filtered) == 0 || a.cursor >= len(a.filtered) {
-		return nil
+// headerRow returns the names of the display attributes lined up with
+// their columns, the sort column marked with the sort direction.
+func (a *App) headerRow() string {
+	names := a.fitColumns(slices.Clone(a.displayAttrs))
+	if i := a.sortColumn; i >= 0 && i < len(names) && a.sortBy == "" && !a.sortRows {
+		if a.sortDesc {
+			names[i] += " ↓"
+		} else {
+			names[i] += " ↑"
+		}
 	}
+	return a.joinDisplayValues(names)
+}
 
-	if len(a.selected) > 0 {
-		result := make([]int, 0, len(a.selected))
-		for idx := range a.selected {
-			result = append(result, idx)
+// columnAt returns the column of the table drawn at a screen column, or -1
+// left of the first one.
+func (a *App) columnAt(col int) int {
+	// Columns start after the cursor prefix and are 2 cells apart
+	x := col - 3
+	if a.truncate {
+		x += a.hscroll
+	}
+	if x < 0 {
+		return -1
+	}
+	for i := range a.displayAttrs {
+		if i == len(a.displayAttrs)-1 || i >= len(a.colWidths) || x < a.colWidths[i]+2 {
+			return i
 		}
-		sort.Ints(result)
-		return result
+		x -= a.colWidths[i] + 2
 	}
+	return -1
+}
 
-	return []int{a.filtered[a.cursor]}
+// crumbHeight returns the number of lines taken by the path of the value
+// being browsed after descending into an item.
+func (a *App) crumbHeight() int {
+	if len(a.drill) > 0 {
+		return 1
+	}
+	return 0
 }
 
-func (a *App) handleBackspace() {
-	if len(a.filter) > 0 {
-		a.filter = a.filter[:len(a.filter)-1]
-		a.updateFilter()
+// matchCounter formats the number of matching items out of all items.
+func (a *App) matchCounter() string {
+	counter := fmt.Sprintf("%d/%d", len(a.filtered), len(a.objects))
+	if a.stream != nil {
+		// More items are on their way
+		counter += "+"
 	}
+	return counter
 }
 
-func (a *App) handleCharacter(ch byte) {
-	if ch >= 32 && ch < 127 {
-		a.filter += string(ch)
-		a.updateFilter()
+// modes describes the sorting and matching modes in effect, e.g. "regex".
+func (a *App) modes() []string {
+	var modes []string
+	attr := a.sortKey()
+	if a.sortRows {
+		attr = "row"
+	}
+	if attr != "" {
+		arrow := "↑"
+		if a.sortDesc {
+			arrow = "↓"
+		}
+		modes = append(modes, fmt.Sprintf("sort: %s %s", attr, arrow))
 	}
+	if a.unique != nil && a.unique.removed > 0 {
+		modes = append(modes, plural(a.unique.removed, "duplicate")+" removed")
+	}
+	if a.regex {
+		modes = append(modes, "regex")
+	}
+	switch a.caseMode {
+	case caseIgnore:
+		modes = append(modes, "ignore case")
+	case caseMatch:
+		modes = append(modes, "match case")
+	}
+	if a.searchAll {
+		modes = append(modes, "all fields")
+	}
+	if a.normalMode {
+		modes = append(modes, "normal")
+	}
+	return modes
 }
 
-func (a *App) moveCursorUp() {
-	if a.cursor > 0 {
-		a.cursor--
+// drawStatusLine draws the last line of the screen for --info status: the
+// match counter, the displayed attributes and the modes in effect.
+func (a *App) drawStatusLine(frame *bytes.Buffer) {
+	status := a.matchCounter()
+	if len(a.displayAttrs) > 0 {
+		status += "  " + strings.Join(a.displayAttrs, ", ")
+	} else {
+		status += "  whole object"
+	}
+	if len(a.selected) > 0 {
+		status += fmt.Sprintf("  [%d selected]", len(a.selected))
+	}
+	for _, mode := range a.modes() {
+		status += "  [" + mode + "]"
 	}
+
+	// Stay clear of the last column, where the terminal could scroll
+	a.moveTo(frame, a.height, 1)
+	fmt.Fprintf(frame, "%s%s %s%s", clearLine, colorReverse, truncateWidth(status, a.width-2), colorReset)
 }
 
-func (a *App) moveCursorDown() {
-	if a.cursor < len(a.filtered)-1 {
-		a.cursor++
+// infoHeight returns the number of lines taken by the match counter.
+func (a *App) infoHeight() int {
+	if a.info == "default" || a.info == "status" {
+		return 1
 	}
+	return 0
 }
 
-func (a *App) handleInput(buf []byte, n int) (done bool, result []int) {
-	if n == 1 {
-		switch buf[0] {
-		case 0: // Ctrl+Space
-			a.toggleSelection()
-			a.render()
-		case 3, 27: // Ctrl+C or ESC
-			return true, nil
-		case 10, 13: // Enter
-			return true, a.getSelection()
-		case 127: // Backspace
-			a.handleBackspace()
-			a.render()
-		default:
-			a.handleCharacter(buf[0])
-			a.render()
+// previewHeight returns the number of lines taken by the preview pane at
+// the bottom, including its border.
+func (a *App) previewHeight() int {
+	if !a.showPreview || a.previewSide == "right" {
+		return 0
+	}
+	return a.height / 2
+}
+
+// previewWidth returns the number of columns taken by the preview pane on
+// the right, including its border.
+func (a *App) previewWidth() int {
+	if !a.showPreview || a.previewSide != "right" {
+		return 0
+	}
+	return a.width / 2
+}
+
+// listWidth returns the number of columns left for the list.
+func (a *App) listWidth() int {
+	return a.width - a.previewWidth() - a.facetWidth()
+}
+
+// previewLines returns the highlighted object pretty-printed, or nothing
+// when no item matches.
+func (a *App) previewLines() []string {
+	if len(a.filtered) == 0 || a.cursor >= len(a.filtered) {
+		return nil
+	}
+	idx := a.filtered[a.cursor]
+	var jsonBytes []byte
+	if raw := a.rawObject(idx); raw != nil {
+		var buf bytes.Buffer
+		if json.Indent(&buf, raw, "", "  ") != nil {
+			return nil
 		}
-	} else if n == 3 && buf[0] == 27 && buf[1] == 91 {
-		switch buf[2] {
-		case 65: // Up arrow
-			a.moveCursorUp()
-			a.render()
-		case 66: // Down arrow
-			a.moveCursorDown()
-			a.render()
+		jsonBytes = buf.Bytes()
+	} else {
+		var err error
+		jsonBytes, err = json.MarshalIndent(a.objects[idx], "", "  ")
+		if err != nil {
+			return nil
 		}
 	}
-
-	return false, nil
+	return strings.Split(string(jsonBytes), "\n")
 }
 
-func (a *App) run() ([]int, error) {
-	ttyFd := a.tty.Fd()
-	oldState, err := setRawMode(ttyFd)
+// highlightPattern returns the pattern of the parts of display values that
+// match the current filter, or nil when there is nothing to highlight.
+func (a *App) highlightPattern() *regexp.Regexp {
+	if a.filter == "" || a.filterErr != "" || a.jqMode {
+		return nil
+	}
+
+	// The text of the terms matching the row is highlighted, unlike that
+	// of excluded terms and of field:value terms, which match attributes
+	pattern := a.filter
+	if a.regex {
+		if a.regexIgnoresCase(pattern) {
+			pattern = "(?i)" + pattern
+		}
+	} else {
+		var parts []string
+		for _, text := range highlightTerms(a.parseQuery(a.filter), false) {
+			part := a.letterPattern(text)
+			if a.ignoreCase(text) {
+				part = "(?i:" + part + ")"
+			}
+			parts = append(parts, part)
+		}
+		if len(parts) == 0 {
+			return nil
+		}
+		pattern = strings.Join(parts, "|")
+	}
+	re, err := regexp.Compile(pattern)
 	if err != nil {
-		return nil, err
+		return nil
 	}
-	defer restoreTerminal(ttyFd, oldState)
+	return re
+}
 
-	fmt.Fprint(a.tty, altScreenOn+hideCursor)
-	defer fmt.Fprint(a.tty, showCursor+altScreenOff)
+// highlightMatches colors the parts of s matching re. style is the style of
+// the rest of the row, restored after each match.
+func highlightMatches(s string, re *regexp.Regexp, style string) string {
+	if re == nil {
+		return s
+	}
 
-	a.render()
+	var out strings.Builder
+	last := 0
+	for _, match := range re.FindAllStringIndex(s, -1) {
+		if match[0] == match[1] {
+			continue
+		}
+		out.WriteString(s[last:match[0]])
+		out.WriteString(colorMatch + s[match[0]:match[1]] + colorReset + style)
+		last = match[1]
+	}
+	out.WriteString(s[last:])
+	return out.String()
+}
 
-	buf := make([]byte, 3)
-	for {
-		n, err := a.tty.Read(buf)
-		if err != nil {
-			return nil, err
+// renderPreview draws the highlighted object, pretty-printed, in a pane at
+// the bottom or on the right of the screen.
+func (a *App) renderPreview(frame *bytes.Buffer) {
+	if width := a.previewWidth(); width > 2 {
+		// Draw over the right part of the rows, clearing what the list
+		// left there
+		left := a.width - width + 1
+		lines := a.previewLines()
+		for row := 1; row < a.height; row++ {
+			a.moveTo(frame, row, left)
+			fmt.Fprintf(frame, "%s%s│%s ", clearToEOL, colorInfo, colorReset)
+			if row-1 < len(lines) {
+				fmt.Fprint(frame, truncateWidth(lines[row-1], width-2))
+			}
 		}
+		return
+	}
+
+	height := a.previewHeight()
+	if height < 2 {
+		return
+	}
+
+	top := a.height - height + 1
+	if a.info == "status" {
+		// Keep the last line for the status line
+		top--
+	}
+	a.moveTo(frame, top, 1)
+	fmt.Fprintf(frame, "%s%s%s", colorInfo, strings.Repeat("─", a.width), colorReset)
+
+	lines := a.previewLines()
+	for i := 0; i < len(lines) && i < height-1; i++ {
+		fmt.Fprintf(frame, "\r\n%s", truncateWidth(lines[i], a.width))
+	}
+}
 
-		if done, result := a.handleInput(buf, n); done {
-			return result, nil
+// toggleSelection toggles the item under the cursor. Selections are keyed by
+// object index, so they survive filter changes; only selected items are kept
+// in the map so that its size is the number of selected items.
+func (a *App) toggleSelection() {
+	if len(a.filtered) > 0 && a.cursor < len(a.filtered) {
+		idx := a.filtered[a.cursor]
+		if a.selected[idx] > 0 {
+			delete(a.selected, idx)
+		} else {
+			a.selectItem(idx)
+		}
+		if a.cursor < len(a.filtered)-1 {
+			a.cursor++
 		}
 	}
 }
 
-func max(a, b int) int {
-	if a > b {
-		return a
+// selectVisible changes the selection of every item that passes the current
+// filter: select adds them, otherwise they are removed, or with toggle each
+// one is flipped. Selected items hidden by the filter are left alone.
+func (a *App) selectVisible(selectItems, toggle bool) {
+	for _, idx := range a.filtered {
+		if toggle {
+			selectItems = a.selected[idx] == 0
+		}
+		if selectItems {
+			a.selectItem(idx)
+		} else {
+			delete(a.selected, idx)
+		}
 	}
-	return b
 }
 
-func min(a, b int) int {
-	if a < b {
-		return a
+// selectItem selects the item at idx, numbering it after the items selected
+// before it unless it already is selected.
+func (a *App) selectItem(idx int) {
+	if a.selected[idx] == 0 {
+		a.selections++
+		a.selected[idx] = a.selections
 	}
-	return b
 }
 
-type config struct {
-	outputAttr   string
-	displayAttrs []string
-	truncate     bool
-	tableMode    bool
-	lineMode     bool
-	allAttrs     bool
-	filename     string
-	separator    string
+// selectionOrder returns indices, a selection, in the order its items were
+// selected. Items that aren't selected, such as the one under the cursor
+// when nothing is, keep their order.
+func (a *App) selectionOrder(indices []int) []int {
+	ordered := slices.Clone(indices)
+	slices.SortStableFunc(ordered, func(i, j int) int {
+		return a.selected[i] - a.selected[j]
+	})
+	return ordered
 }
 
-func outputUsage() {
-	fmt.Fprintln(os.Stderr, "Usage: qjp [filename] [-d display-attribute] [-o output-attribute] [-s separator] [-t] [-T] [-l] [-a]")
-	fmt.Fprintln(os.Stderr, "       qjp [-d display-attribute] [-o output-attribute] [-s separator] [-t] [-T] [-l] [-a] < input")
-	fmt.Fprintln(os.Stderr, "")
-	fmt.Fprintln(os.Stderr, "Input can be provided via stdin or filename, but not both.")
-	fmt.Fprintln(os.Stderr, "If no display-attribute is provided, the whole object is displayed.")
-	fmt.Fprintln(os.Stderr, "")
-	fmt.Fprintln(os.Stderr, "Options:")
-	fmt.Fprintln(os.Stderr, "  -d <attr>  Display specific attribute in list (can be used multiple times)")
-	fmt.Fprintln(os.Stderr, "  -o <attr>  Output specific attribute from selected object(s)")
-	fmt.Fprintln(os.Stderr, "  -s <sep>   Separator for multiple display attributes (default: \" - \")")
-	fmt.Fprintln(os.Stderr, "  -t         Truncate long lines instead of wrapping")
-	fmt.Fprintln(os.Stderr, "  -T         Table mode: align attributes in columns")
-	fmt.Fprintln(os.Stderr, "  -l         Line mode: treat input as plain text lines (like percol)")
-	fmt.Fprintln(os.Stderr, "  -a         Display all attributes (cannot be used with -d)")
-	fmt.Fprintln(os.Stderr, "")
-	fmt.Fprintln(os.Stderr, "Controls:")
-	fmt.Fprintln(os.Stderr, "  Arrow Keys    Navigate up/down")
-	fmt.Fprintln(os.Stderr, "  Ctrl+Space    Toggle selection (multi-select)")
-	fmt.Fprintln(os.Stderr, "  Enter         Confirm selection")
-	fmt.Fprintln(os.Stderr, "  ESC/Ctrl+C    Cancel")
-	fmt.Fprintln(os.Stderr, "")
-	fmt.Fprintln(os.Stderr, "Examples:")
-	fmt.Fprintln(os.Stderr, "  qjp yourfile.json -d display_attribute -o output_attribute")
-	fmt.Fprintln(os.Stderr, "  qjp -d name -d id -T < data.json")
-	fmt.Fprintln(os.Stderr, "  cat file.txt | qjp -l")
+// toggleTruncate switches between truncating and wrapping long rows.
+func (a *App) toggleTruncate() {
+	a.truncate = !a.truncate
+	a.hscroll = 0
 }
 
-func parseArgs() config {
-	cfg := config{
-		separator: " - ",
+// getSelection returns the selected object indices, including selected items
+// hidden by the current filter. Without selections it returns the item under
+// the cursor.
+func (a *App) getSelection() []int {
+	if len(a.selected) > 0 {
+		result := make([]int, 0, len(a.selected))
+		for idx := range a.selected {
+			result = append(result, idx)
+		}
+		sort.Ints(result)
+		return result
 	}
 
-	args := os.Args[1:]
-	for i := 0; i < len(args); i++ {
-		switch args[i] {
-		case "-d":
-			if i+1 < len(args) {
-				cfg.displayAttrs = append(cfg.displayAttrs, args[i+1])
-				i++
-			}
-		case "-o":
-			if i+1 < len(args) {
-				cfg.outputAttr = args[i+1]
-				i++
-			}
-		case "-s":
-			if i+1 < len(args) {
-				cfg.separator = args[i+1]
-				i++
-			}
-		case "-t":
-			cfg.truncate = true
-		case "-T":
-			cfg.tableMode = true
-		case "-l":
-			cfg.lineMode = true
-		case "-a":
-			cfg.allAttrs = true
-		case "-h", "--help":
-			outputUsage()
-			os.Exit(0)
-		default:
-			if !strings.HasPrefix(args[i], "-") {
-				cfg.filename = args[i]
-			}
-		}
+	if len(a.filtered) == 0 || a.cursor >= len(a.filtered) {
+		return nil
 	}
 
-	return cfg
+	return []int{a.filtered[a.cursor]}
 }
 
-func validateConfig(cfg config) error {
-	if cfg.allAttrs && len(cfg.displayAttrs) > 0 {
-		return fmt.Errorf("cannot use both -a and -d")
+// setInitialCursor places the cursor on the item at index, or on the first
+// item whose attribute matches the "attr=value" match expression. The cursor
+// stays on the first item if nothing qualifies, and false is returned.
+func (a *App) setInitialCursor(index int, match string) bool {
+	if index >= 0 && index < len(a.filtered) {
+		a.cursor = index
+		return true
 	}
 
-	if cfg.lineMode {
-		if len(cfg.displayAttrs) > 0 {
-			return fmt.Errorf("cannot use -d in line mode")
+	if match == "" {
+		return false
+	}
+
+	attr, want, _ := strings.Cut(match, "=")
+	for i, idx := range a.filtered {
+		val, ok := a.attrValue(a.objects[idx], attr)
+		if !ok {
+			continue
 		}
-		if cfg.allAttrs {
-			return fmt.Errorf("cannot use -a in line mode")
+		if formatted, err := formatOutputValue(val); err == nil && formatted == want {
+			a.cursor = i
+			return true
 		}
-		if cfg.outputAttr != "" {
-			return fmt.Errorf("cannot use -o in line mode")
+	}
+	return false
+}
+
+// preselect selects the items, from index from on, whose preselectBy value
+// is one of preselected.
+func (a *App) preselect(from int) {
+	wanted := make(map[string]bool, len(a.preselected))
+	for _, v := range a.preselected {
+		wanted[v] = true
+	}
+
+	for i := from; i < len(a.objects); i++ {
+		val, ok := a.attrValue(a.objects[i], a.preselectBy)
+		if !ok {
+			continue
 		}
-		if cfg.separator != " - " {
-			return fmt.Errorf("cannot use -s in line mode")
+		if formatted, err := formatOutputValue(val); err == nil && wanted[formatted] {
+			a.selectItem(i)
 		}
-		if cfg.truncate {
-			return fmt.Errorf("cannot use -t in line mode")
+	}
+}
+
+// readPreselectFile reads the values to preselect from a file, one per line.
+func readPreselectFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line != "" {
+			values = append(values, line)
 		}
-		if cfg.tableMode {
-			return fmt.Errorf("cannot use -T in line mode")
+	}
+	return values, nil
+}
+
+// itemIdentity returns a string identifying the object at idx across
+// reloads. Objects are identified by the key attribute when one is set and
+// by their position in the input otherwise.
+func (a *App) itemIdentity(idx int) (string, bool) {
+	if a.treeMode && a.key == "" {
+		return a.paths[idx].object, true
+	}
+	if a.key == "" {
+		return strconv.Itoa(idx), true
+	}
+
+	val, ok := a.attrValue(a.objects[idx], a.key)
+	if !ok {
+		return "", false
+	}
+	id, err := formatOutputValue(val)
+	if err != nil {
+		return "", false
+	}
+	return id, true
+}
+
+// replaceObjects swaps in a freshly loaded list of objects, keeping the
+// current filter and trying to keep the cursor and the multi-selection on
+// the same logical items.
+func (a *App) replaceObjects(objects []map[string]interface{}, paths []itemPath) {
+	a.ascendToTop()
+	cursorID, hasCursor := "", false
+	if a.cursor < len(a.filtered) {
+		cursorID, hasCursor = a.itemIdentity(a.filtered[a.cursor])
+	}
+
+	selectedIDs := make(map[string]int)
+	for idx, order := range a.selected {
+		if id, ok := a.itemIdentity(idx); ok {
+			selectedIDs[id] = order
 		}
 	}
 
-	return nil
+	a.objects = objects
+	a.paths = paths
+	a.attrs = nil
+	if a.tableMode && len(a.displayAttrs) > 0 {
+		a.calculateColumnWidths()
+	}
+	a.computeBaseItems()
+	a.updateFilter()
+
+	a.selected = make(map[int]int)
+	for idx := range a.objects {
+		if id, ok := a.itemIdentity(idx); ok && selectedIDs[id] > 0 {
+			a.selected[idx] = selectedIDs[id]
+		}
+	}
+
+	if hasCursor {
+		for i, idx := range a.filtered {
+			if id, ok := a.itemIdentity(idx); ok && id == cursorID {
+				a.cursor = i
+				return
+			}
+		}
+	}
+	if a.cursor >= len(a.filtered) {
+		a.cursor = max(0, len(a.filtered)-1)
+	}
 }
 
-func readInput(filename string) ([]byte, error) {
-	stdinStat, _ := os.Stdin.Stat()
-	hasStdin := (stdinStat.Mode() & os.ModeCharDevice) == 0
+// streamObjects returns the channel of the input stream, or nil once the
+// whole input has been read.
+func (a *App) streamObjects() chan streamedObject {
+	if a.stream == nil {
+		return nil
+	}
+	return a.stream.objects
+}
 
-	if hasStdin && filename != "" {
-		return nil, fmt.Errorf("cannot use both stdin and filename input")
+// readStream adds item and the objects following it in the input stream to
+// the list. ok is false at the end of the input, when a read error is shown
+// next to the filter.
+func (a *App) readStream(item streamedObject, ok bool) {
+	var objects []map[string]interface{}
+	var raws []json.RawMessage
+	first := a.stream.read
+	if ok {
+		objects, raws, ok = a.stream.batch(item)
+	}
+	if !ok {
+		if a.stream.err != nil {
+			a.message = fmt.Sprintf("input error: %v", a.stream.err)
+		}
+		a.stream = nil
+	}
+	if len(objects) > 0 {
+		a.appendObjects(a.prepare(objects, raws, first))
+	}
+}
+
+// appendObjects adds objects to the end of the list, keeping the cursor on
+// the same item.
+func (a *App) appendObjects(objects []map[string]interface{}, paths []itemPath) {
+	if len(a.drill) > 0 {
+		// Shown once back at the top
+		top := &a.drill[0]
+		top.objects = append(top.objects, objects...)
+		top.paths = append(top.paths, paths...)
+		return
+	}
+	cursorIdx := -1
+	if a.cursor < len(a.filtered) {
+		cursorIdx = a.filtered[a.cursor]
+	}
+
+	from := len(a.objects)
+	a.objects = append(a.objects, objects...)
+	a.paths = append(a.paths, paths...)
+	if a.attrs != nil {
+		a.attrs.add(objects)
+	}
+	if a.allAttrs {
+		a.displayAttrs = getAllAttributes(a.objects)
+		for _, col := range a.columns {
+			a.displayAttrs = append(a.displayAttrs, col.name)
+		}
+	}
+	if a.tableMode && len(a.displayAttrs) > 0 {
+		a.calculateColumnWidths()
+	}
+	a.computeBaseItems()
+	a.updateFilter()
+	if len(a.preselected) > 0 {
+		a.preselect(from)
+	}
+
+	for i, idx := range a.filtered {
+		if idx == cursorIdx {
+			a.cursor = i
+			break
+		}
+	}
+	if (a.defaultIndex >= 0 || a.defaultMatch != "") && a.setInitialCursor(a.defaultIndex, a.defaultMatch) {
+		a.defaultIndex, a.defaultMatch = -1, ""
+	}
+}
+
+// reload re-reads the input source and replaces the objects. On failure the
+// current objects are kept and the error is shown next to the filter.
+func (a *App) reload() {
+	if a.load == nil {
+		return
+	}
+
+	objects, paths, err := a.load()
+	if err != nil {
+		a.message = fmt.Sprintf("reload failed: %v", err)
+		return
+	}
+	a.message = ""
+	a.replaceObjects(objects, paths)
+}
+
+func (a *App) handleBackspace() {
+	if a.filter == "" && len(a.filterStack) == 0 {
+		a.ascend()
+		return
+	}
+	if a.filter == "" {
+		a.popFilter()
+		return
+	}
+	if len(a.filter) > 0 {
+		a.filter = trimLastGrapheme(a.filter)
+		a.updateFilter()
+	}
+}
+
+// handleCharacter types a printable character into the filter.
+func (a *App) handleCharacter(key string) {
+	if isPrintableKey(key) {
+		a.filter += key
+		a.updateFilter()
+	}
+}
+
+// resize picks up the new size of the terminal. A replayed session keeps
+// its recorded size. In inline mode the region is recomputed and reserved
+// again, as the terminal may have reflowed it.
+func (a *App) resize() {
+	if a.replay != nil {
+		return
+	}
+	a.width, a.height, _ = getTerminalSize(a.ttyOut)
+	if a.inlineHeight != "" {
+		a.height, _ = parseHeight(a.inlineHeight, a.height)
+		fmt.Fprint(a.out, restoreCursor+clearToEOS)
+		a.enterScreen()
+	}
+	a.scrollHorizontally(0)
+}
+
+// scrollHorizontally shifts all rows by delta columns in truncate mode, so
+// the truncated tail of long values can be inspected.
+func (a *App) scrollHorizontally(delta int) {
+	if !a.truncate {
+		return
+	}
+	maxScroll := max(0, a.getMaxDisplayWidth()-(a.listWidth()-2))
+	a.hscroll = min(max(0, a.hscroll+delta), maxScroll)
+}
+
+func (a *App) moveCursorUp() {
+	if a.cursor > 0 {
+		a.cursor--
+	}
+}
+
+// moveCursorPage moves the cursor by a screenful of items, up when pages is
+// negative.
+func (a *App) moveCursorPage(pages int) {
+	if len(a.filtered) == 0 {
+		return
+	}
+	a.cursor = min(max(0, a.cursor+pages*a.listHeight()), len(a.filtered)-1)
+}
+
+// moveCursorTo moves the cursor to the first item, or to the last one when
+// last is set.
+func (a *App) moveCursorTo(last bool) {
+	a.cursor = 0
+	if last && len(a.filtered) > 0 {
+		a.cursor = len(a.filtered) - 1
+	}
+}
+
+func (a *App) moveCursorDown() {
+	if a.cursor < len(a.filtered)-1 {
+		a.cursor++
+	}
+}
+
+func (a *App) handleInput(buf []byte) (done bool, result []int) {
+	// Once the user takes over, items arriving later no longer move the
+	// cursor to --default-index or --default-match
+	a.defaultIndex, a.defaultMatch = -1, ""
+	for _, key := range parseKeys(buf) {
+		if ev, ok := parseMouse(key); ok {
+			if done, result := a.handleMouse(ev); done {
+				return true, result
+			}
+		} else if a.popup != nil {
+			a.handlePopupKey(key)
+		} else if a.facets != nil && a.facets.focus && a.handleFacetKey(key) {
+			// Moved through the sidebar
+		} else if act, ok := a.normalKeys[key]; ok && a.normalMode {
+			if done, result := a.runAction(act); done {
+				return true, result
+			}
+		} else if act, ok := a.bindings[key]; ok {
+			if done, result := a.runAction(act); done {
+				return true, result
+			}
+		} else if !a.normalMode {
+			a.handleCharacter(key)
+		}
+	}
+
+	a.render()
+	return false, nil
+}
+
+// minInlineHeight is the smallest region --height reserves: the filter
+// line, the counter line and a few items.
+const minInlineHeight = 5
+
+// parseHeight turns a --height value, a number of lines or a percentage of
+// the terminal height, into the number of lines to use on a terminal of
+// termHeight lines.
+func parseHeight(spec string, termHeight int) (int, error) {
+	pct, isPct := strings.CutSuffix(spec, "%")
+	n, err := strconv.Atoi(pct)
+	if err != nil || n <= 0 || (isPct && n > 100) {
+		return 0, fmt.Errorf("--height expects a number of lines or a percentage, e.g. 40%%")
+	}
+	if isPct {
+		n = termHeight * n / 100
+	}
+	return min(max(n, minInlineHeight), termHeight), nil
+}
+
+// enterScreen takes the terminal over: the alternate screen, or in inline
+// mode a region of a.height lines below the cursor. The terminal scrolls if
+// there is not enough room, and the top left corner of the region is saved
+// so that frames can be drawn relative to it.
+func (a *App) enterScreen() {
+	if a.inlineHeight == "" {
+		fmt.Fprint(a.out, altScreenOn+hideCursor)
+		if a.mouse {
+			fmt.Fprint(a.out, mouseOn)
+		}
+		return
+	}
+	fmt.Fprint(a.out, hideCursor)
+	a.openRegion()
+}
+
+// openRegion makes room for a.height lines below the cursor, scrolling the
+// terminal if needed, and saves their top left corner.
+func (a *App) openRegion() {
+	fmt.Fprint(a.out, "\r"+strings.Repeat("\n", a.height-1))
+	if a.height > 1 {
+		fmt.Fprintf(a.out, "\033[%dA", a.height-1)
+	}
+	fmt.Fprint(a.out, saveCursor)
+}
+
+// leaveScreen gives the terminal back. In inline mode the region is erased,
+// leaving the cursor where the picker started.
+func (a *App) leaveScreen() {
+	if a.inlineHeight == "" {
+		if a.mouse {
+			fmt.Fprint(a.out, mouseOff)
+		}
+		fmt.Fprint(a.out, showCursor+altScreenOff)
+		return
+	}
+	fmt.Fprint(a.out, restoreCursor+clearToEOS+showCursor)
+}
+
+// keepFrame draws the final frame on the normal screen for --keep-output,
+// after leaveScreen. Out of inline mode, it is drawn in a region opened
+// below the cursor as inline mode does, so that the status line, the
+// preview pane and the facets, which are drawn at rows of the frame, land
+// in it instead of over the scrollback. The cursor is left below the frame.
+func (a *App) keepFrame() {
+	if a.inlineHeight == "" {
+		a.openRegion()
+		a.inlineHeight = strconv.Itoa(a.height)
+		defer func() { a.inlineHeight = "" }()
+	}
+	var frame bytes.Buffer
+	a.drawFrame(&frame)
+	a.moveTo(&frame, a.height, 1)
+	frame.WriteString("\r\n")
+	a.out.Write(frame.Bytes())
+}
+
+// moveTo moves the cursor to a 1-based row and column of the area qjp draws
+// in, which in inline mode starts at the saved cursor position.
+func (a *App) moveTo(frame *bytes.Buffer, row, col int) {
+	if a.inlineHeight == "" {
+		fmt.Fprintf(frame, "\033[%d;%dH", row, col)
+		return
+	}
+	frame.WriteString(restoreCursor)
+	if row > 1 {
+		fmt.Fprintf(frame, "\033[%dB", row-1)
+	}
+	if col > 1 {
+		fmt.Fprintf(frame, "\033[%dC", col-1)
+	}
+}
+
+// suspend hands the terminal back to its normal state, e.g. to run an
+// interactive command.
+func (a *App) suspend() {
+	a.leaveScreen()
+	restoreTerminal(a.tty.Fd(), a.termState)
+}
+
+// resume takes the terminal over again after suspend.
+func (a *App) resume() {
+	_, _ = setRawMode(a.tty.Fd())
+	a.enterScreen()
+	a.render()
+}
+
+func (a *App) run() ([]int, error) {
+	ttyFd := a.tty.Fd()
+	oldState, err := setRawMode(ttyFd)
+	if err != nil {
+		return nil, err
+	}
+	a.termState = oldState
+	defer restoreTerminal(ttyFd, oldState)
+
+	a.enterScreen()
+	defer func() {
+		a.leaveScreen()
+		if a.keepOutput {
+			a.keepFrame()
+		}
+	}()
+
+	a.render()
+	defer close(a.quit)
+
+	keys := make(chan []byte)
+	keysDone := make(chan struct{})
+	readErrs := make(chan error, 1)
+	if a.replay != nil {
+		go a.replayKeys(a.replay, keys, keysDone, readErrs)
+	} else {
+		go a.readKeys(keys, keysDone, readErrs)
+	}
+
+	reloads := make(chan os.Signal, 1)
+	notifyReload(reloads)
+	defer signal.Stop(reloads)
+
+	resizes := make(chan os.Signal, 1)
+	notifyResize(resizes, a.ttyOut)
+	defer signal.Stop(resizes)
+
+	var watchTicks <-chan time.Time
+	if a.watch > 0 && a.load != nil && a.replay == nil {
+		ticker := time.NewTicker(a.watch)
+		defer ticker.Stop()
+		watchTicks = ticker.C
+	}
+
+	for {
+		select {
+		case buf := <-keys:
+			if a.recorder != nil {
+				a.recorder.recordKeys(buf)
+			}
+			if done, result := a.handleInput(buf); done {
+				return result, nil
+			}
+			keysDone <- struct{}{}
+		case err := <-readErrs:
+			return nil, err
+		case <-reloads:
+			a.reload()
+			a.render()
+		case <-watchTicks:
+			a.reload()
+			a.render()
+		case <-resizes:
+			a.resize()
+			a.render()
+		case item, ok := <-a.streamObjects():
+			a.readStream(item, ok)
+			a.render()
+		case err := <-a.jobs:
+			a.message = fmt.Sprintf("execute-silent failed: %v", err)
+			a.render()
+		case cmd := <-a.commands:
+			done, result, reply := a.handleControl(cmd.line)
+			fmt.Fprintln(cmd.conn, reply)
+			if done {
+				return result, nil
+			}
+			a.render()
+		}
+	}
+}
+
+// readKeys forwards raw reads from the tty to keys until reading fails. It
+// waits on done before reading again, so that commands run by a key binding
+// get the terminal input to themselves.
+func (a *App) readKeys(keys chan<- []byte, done <-chan struct{}, errs chan<- error) {
+	buf := make([]byte, 64)
+	for {
+		n, err := a.tty.Read(buf)
+		if err != nil {
+			errs <- err
+			return
+		}
+		keys <- append([]byte(nil), buf[:n]...)
+		<-done
+	}
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+type config struct {
+	outputAttrs  []string
+	displayAttrs []string
+	truncate     bool
+	tableMode    bool
+	lineMode     bool
+	yaml         bool
+	toml         bool
+	csv          bool
+	tsv          bool
+	entries      bool
+	jq           string
+	tree         bool
+	facet        string
+	groupBy      string
+	unique       bool
+	uniqueBy     string
+	index        bool
+	ndjson       bool
+	colLimits    map[string]int
+	allAttrs     bool
+	filename     string
+	separator    string
+	defaultIndex int
+	defaultMatch string
+	key          string
+	controlPath  string
+	bindings     []string
+	linkField    string
+	recordPath   string
+	replayPath   string
+	explode      string
+	columns      []string
+	keepOutput   bool
+	info         string
+	merge        bool
+	preselect    []string
+	printJQPath  bool
+	execCmd      string
+	loop         bool
+	pretty       bool
+	sortAttr     string
+	tac          bool
+	inputCmd     string
+	url          string
+	timeout      string
+	watch        string
+	bidi         bool
+	multi        bool
+	preview      string
+	height       string
+	delimiter    string
+	format       string
+	regex        bool
+	literal      bool
+	searchAll    bool
+	query        string
+	select1      bool
+	exit0        bool
+	filter       string
+	filterSet    bool // whether -f was given, even with no text
+	noMouse      bool
+	vi           bool
+	args         []string
+}
+
+func outputUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: qjp [filename] [display-attribute...] [-d display-attribute] [-o output-attribute] [-s separator] [-t] [-T] [-l] [-a] [options]")
+	fmt.Fprintln(os.Stderr, "       qjp [display-attribute...] [-d display-attribute] [-o output-attribute] [-s separator] [-t] [-T] [-l] [-a] [options] < input")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Input can be provided via stdin or filename, but not both.")
+	fmt.Fprintln(os.Stderr, "If no display-attribute is provided, the whole object is displayed.")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Options:")
+	fmt.Fprintln(os.Stderr, "  -d <attr>  Display specific attribute in list (can be used multiple times)")
+	fmt.Fprintln(os.Stderr, "  -o <attr>  Output specific attribute from selected object(s) (can be used multiple times)")
+	fmt.Fprintln(os.Stderr, "  -s <sep>   Separator for multiple display attributes (default: \" - \")")
+	fmt.Fprintln(os.Stderr, "  -t         Truncate long lines instead of wrapping")
+	fmt.Fprintln(os.Stderr, "  -T         Table mode: align attributes in columns under a header")
+	fmt.Fprintln(os.Stderr, "  -l         Line mode: treat input as plain text lines (like percol)")
+	fmt.Fprintln(os.Stderr, "  -a         Display all attributes (cannot be used with -d)")
+	fmt.Fprintln(os.Stderr, "  -m, --multi                 Multi-select: Tab toggles items, marked with *")
+	fmt.Fprintln(os.Stderr, "  --default-index <n>         Start with the cursor on the n-th item (0-based)")
+	fmt.Fprintln(os.Stderr, "  --default-match <attr=val>  Start with the cursor on the first item whose attr equals val")
+	fmt.Fprintln(os.Stderr, "  --key <attr>                Attribute identifying items across reloads")
+	fmt.Fprintln(os.Stderr, "  --control-socket <path>     Accept control commands on a unix socket")
+	fmt.Fprintln(os.Stderr, "  --bind <key:action,...>     Bind keys to actions, e.g. ctrl-o:execute(less {})")
+	fmt.Fprintln(os.Stderr, "  --link-field <attr>         Attribute holding the URL each item links to")
+	fmt.Fprintln(os.Stderr, "  --record <file>             Record input data and keystrokes to a session file")
+	fmt.Fprintln(os.Stderr, "  --replay <file>             Replay a recorded session")
+	fmt.Fprintln(os.Stderr, "  --profile <name>            Use the options of a profile from the config file")
+	fmt.Fprintln(os.Stderr, "  --explode <attr>            Turn each element of an array attribute into its own item")
+	fmt.Fprintln(os.Stderr, "  --columns <attr[:w],...>    Show attributes as table columns cut at w cells (default 40)")
+	fmt.Fprintln(os.Stderr, "  --column <name=expr>        Add a computed column, e.g. total=price*qty")
+	fmt.Fprintln(os.Stderr, "  --keep-output               Leave the final list on the screen after exiting")
+	fmt.Fprintln(os.Stderr, "  --info <style>              Match counter style: default, inline, hidden or status")
+	fmt.Fprintln(os.Stderr, "  --merge                     Deep-merge the selected objects into one")
+	fmt.Fprintln(os.Stderr, "  --preselect <value>         Start with the items having this value selected")
+	fmt.Fprintln(os.Stderr, "  --preselect-file <file>     Start with the items having a value listed in file selected")
+	fmt.Fprintln(os.Stderr, "  --print-jq-path             Output the jq path of the selection, e.g. .[42]")
+	fmt.Fprintln(os.Stderr, "  --bidi                      Reorder right-to-left text for terminals without bidi support")
+	fmt.Fprintln(os.Stderr, "  --preview <position>        Show the preview pane at the bottom or on the right")
+	fmt.Fprintln(os.Stderr, "  --height <n|n%>             Draw below the cursor on n lines (or n% of the terminal)")
+	fmt.Fprintln(os.Stderr, "  --delimiter <string>        Delimiter between several -o attributes (default: tab)")
+	fmt.Fprintln(os.Stderr, "  --regex                     Match the filter as a regular expression (Alt+R toggles)")
+	fmt.Fprintln(os.Stderr, "  --literal                   Don't match accented letters by their base letter")
+	fmt.Fprintln(os.Stderr, "  -q, --query <text>          Start with this filter, which can still be edited")
+	fmt.Fprintln(os.Stderr, "  --vi                        Esc enters a normal mode where j/k move and / edits the filter")
+	fmt.Fprintln(os.Stderr, "  --no-mouse                  Leave the mouse to the terminal")
+	fmt.Fprintln(os.Stderr, "  -f, --filter <text>         Output the items matching text without opening the UI")
+	fmt.Fprintln(os.Stderr, "  -1, --select-1              Output the only matching item without opening the UI")
+	fmt.Fprintln(os.Stderr, "  -0, --exit-0                Exit with status 1 without opening the UI when nothing matches")
+	fmt.Fprintln(os.Stderr, "  --search-all                Match the filter against whole objects (Alt+A toggles)")
+	fmt.Fprintln(os.Stderr, "  --format <template>         Output selected objects through a Go template, e.g. '{{.id}}:{{.name}}'")
+	fmt.Fprintln(os.Stderr, "  --cmd <command>             Read the output of command; Ctrl+R runs it again")
+	fmt.Fprintln(os.Stderr, "  --url <url>                 Read the response to a GET request; Ctrl+R fetches it again")
+	fmt.Fprintln(os.Stderr, "  --timeout <duration>        Timeout of --url requests (default: 30s)")
+	fmt.Fprintln(os.Stderr, "  --watch <interval>          Reload the input file, --cmd or --url periodically, e.g. 5s")
+	fmt.Fprintln(os.Stderr, "  --yaml                      Read YAML: a list of objects, or one object per document")
+	fmt.Fprintln(os.Stderr, "  --toml                      Read TOML: the tables of its one array of tables, or the document")
+	fmt.Fprintln(os.Stderr, "  --jq PROGRAM                Reshape the input with a jq program, e.g. '.items[]'")
+	fmt.Fprintln(os.Stderr, "  --unique[=attr]             Drop items with the same attr as an earlier one, or the same object")
+	fmt.Fprintln(os.Stderr, "  --unique-by <attr>          Same as --unique=attr")
+	fmt.Fprintln(os.Stderr, "  --group-by <attr>           Gather items under collapsible headers by their attr value")
+	fmt.Fprintln(os.Stderr, "  --facet <attr>              Start with a sidebar counting the values of attr")
+	fmt.Fprintln(os.Stderr, "  --tree                      Browse any JSON value as a tree of collapsible nodes")
+	fmt.Fprintln(os.Stderr, "  --index                     Browse an NDJSON file larger than memory, keeping only the -d attributes")
+	fmt.Fprintln(os.Stderr, "  --csv, --tsv                Read CSV or TSV with a header row naming the attributes")
+	fmt.Fprintln(os.Stderr, "  --ndjson                    Read NDJSON: one object per line, even when there is only one")
+	fmt.Fprintln(os.Stderr, "  --tac                       Show items in reverse input order, the last one first")
+	fmt.Fprintln(os.Stderr, "  --sort <[-]attr>            Sort items by attribute, descending with a leading -")
+	fmt.Fprintln(os.Stderr, "  -p, --pretty                Indent output objects over several lines, even when piped")
+	fmt.Fprintln(os.Stderr, "  --loop                      Keep the picker open after Enter; exit with Esc or Ctrl+C")
+	fmt.Fprintln(os.Stderr, "  --exec <command>            Run command for each selected item instead of printing it, e.g. 'ssh {host}'")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Controls:")
+	fmt.Fprintln(os.Stderr, "  Arrow Keys    Navigate up/down (also Ctrl+P/Ctrl+N)")
+	fmt.Fprintln(os.Stderr, "  PgUp/PgDn     Move by a screenful")
+	fmt.Fprintln(os.Stderr, "  Home/End      Jump to the first/last item")
+	fmt.Fprintln(os.Stderr, "  Left/Right    Scroll horizontally (with -t), or collapse/expand with --tree")
+	fmt.Fprintln(os.Stderr, "  Ctrl+O        Open the link of the current item")
+	fmt.Fprintln(os.Stderr, "  F3/F4         Cycle sort column / toggle sort direction")
+	fmt.Fprintln(os.Stderr, "  Alt+</Alt+>   Sort by the previous / next column")
+	fmt.Fprintln(os.Stderr, "  Ctrl+S        Sort rows: ascending, descending, input order")
+	fmt.Fprintln(os.Stderr, "  Ctrl+R        Reload the input file, --cmd or --url")
+	fmt.Fprintln(os.Stderr, "  Ctrl+/        Show/hide the preview pane")
+	fmt.Fprintln(os.Stderr, "  F2            Show the most frequent values of an attribute")
+	fmt.Fprintln(os.Stderr, "  Ctrl+F        Freeze the results and filter within them")
+	fmt.Fprintln(os.Stderr, "  Alt+R         Toggle regular expression matching")
+	fmt.Fprintln(os.Stderr, "  Alt+A         Toggle matching against all fields")
+	fmt.Fprintln(os.Stderr, "  Alt+C         Switch between smart case, ignoring case and matching case")
+	fmt.Fprintln(os.Stderr, "  Alt+J         Toggle editing a jq expression shown for each item")
+	fmt.Fprintln(os.Stderr, "  Alt+Up/Down   Jump to the previous / next group with --group-by")
+	fmt.Fprintln(os.Stderr, "  F5/Alt+F      Show or hide the facet sidebar / move the keys to it")
+	fmt.Fprintln(os.Stderr, "  Tab           Browse the members of the current item or value")
+	fmt.Fprintln(os.Stderr, "  Ctrl+Space    Toggle selection (multi-select)")
+	fmt.Fprintln(os.Stderr, "  Enter         Confirm selection")
+	fmt.Fprintln(os.Stderr, "  ESC/Ctrl+C    Cancel")
+	fmt.Fprintln(os.Stderr, "  Mouse         Click to move, wheel to scroll, double-click to confirm")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Examples:")
+	fmt.Fprintln(os.Stderr, "  qjp yourfile.json -d display_attribute -o output_attribute")
+	fmt.Fprintln(os.Stderr, "  qjp -d name -d id -T < data.json")
+	fmt.Fprintln(os.Stderr, "  cat file.txt | qjp -l")
+}
+
+// maxProfiles bounds profile expansion, in case profiles refer to each other.
+const maxProfiles = 16
+
+// parseArgs parses the command line, expanding --profile with the profiles
+// of the config file.
+func parseArgs(args []string, file configFile) config {
+	cfg := config{
+		separator:    " - ",
+		delimiter:    "\t",
+		defaultIndex: -1,
+		info:         "default",
+		timeout:      "30s",
+	}
+
+	profiles, positionals := 0, 0
+	for i := 0; i < len(args); i++ {
+		// The attribute of --unique is optional, so it is only taken from
+		// --unique=attr
+		if attr, ok := strings.CutPrefix(args[i], "--unique="); ok {
+			cfg.unique, cfg.uniqueBy = true, attr
+			continue
+		}
+		// --option=value is the same as --option value
+		if name, value, ok := strings.Cut(args[i], "="); ok && strings.HasPrefix(name, "--") {
+			args = append(append(append([]string{}, args[:i]...), name, value), args[i+1:]...)
+		}
+
+		switch args[i] {
+		case "-d":
+			if i+1 < len(args) {
+				cfg.displayAttrs = append(cfg.displayAttrs, args[i+1])
+				i++
+			}
+		case "--columns":
+			if i+1 < len(args) {
+				attrs, widths, err := parseColumnList(args[i+1])
+				if err != nil {
+					fatalError("%v", err)
+				}
+				cfg.displayAttrs = append(cfg.displayAttrs, attrs...)
+				if cfg.colLimits == nil {
+					cfg.colLimits = make(map[string]int)
+				}
+				for attr, width := range widths {
+					cfg.colLimits[attr] = width
+				}
+				cfg.tableMode = true
+				i++
+			}
+		case "-o":
+			if i+1 < len(args) {
+				if isJQExpr(args[i+1]) {
+					cfg.outputAttrs = append(cfg.outputAttrs, args[i+1])
+				} else {
+					cfg.outputAttrs = append(cfg.outputAttrs, strings.Split(args[i+1], ",")...)
+				}
+				i++
+			}
+		case "-s":
+			if i+1 < len(args) {
+				cfg.separator = args[i+1]
+				i++
+			}
+		case "-t":
+			cfg.truncate = true
+		case "-T":
+			cfg.tableMode = true
+		case "-l":
+			cfg.lineMode = true
+		case "-a":
+			cfg.allAttrs = true
+		case "-m", "--multi":
+			cfg.multi = true
+		case "--default-index":
+			if i+1 < len(args) {
+				n, err := strconv.Atoi(args[i+1])
+				if err != nil || n < 0 {
+					fatalError("invalid value for --default-index: %s", args[i+1])
+				}
+				cfg.defaultIndex = n
+				i++
+			}
+		case "--default-match":
+			if i+1 < len(args) {
+				cfg.defaultMatch = args[i+1]
+				i++
+			}
+		case "--key":
+			if i+1 < len(args) {
+				cfg.key = args[i+1]
+				i++
+			}
+		case "--control-socket":
+			if i+1 < len(args) {
+				cfg.controlPath = args[i+1]
+				i++
+			}
+		case "--bind":
+			if i+1 < len(args) {
+				cfg.bindings = append(cfg.bindings, args[i+1])
+				i++
+			}
+		case "--link-field":
+			if i+1 < len(args) {
+				cfg.linkField = args[i+1]
+				i++
+			}
+		case "--record":
+			if i+1 < len(args) {
+				cfg.recordPath = args[i+1]
+				i++
+			}
+		case "--replay":
+			if i+1 < len(args) {
+				cfg.replayPath = args[i+1]
+				i++
+			}
+		case "--explode":
+			if i+1 < len(args) {
+				cfg.explode = args[i+1]
+				i++
+			}
+		case "--column":
+			if i+1 < len(args) {
+				cfg.columns = append(cfg.columns, args[i+1])
+				i++
+			}
+		case "--keep-output":
+			cfg.keepOutput = true
+		case "--merge":
+			cfg.merge = true
+		case "--print-jq-path":
+			cfg.printJQPath = true
+		case "--cmd":
+			if i+1 < len(args) {
+				cfg.inputCmd = args[i+1]
+				i++
+			}
+		case "--url":
+			if i+1 < len(args) {
+				cfg.url = args[i+1]
+				i++
+			}
+		case "--timeout":
+			if i+1 < len(args) {
+				cfg.timeout = args[i+1]
+				i++
+			}
+		case "--watch":
+			if i+1 < len(args) {
+				cfg.watch = args[i+1]
+				i++
+			}
+		case "--yaml":
+			cfg.yaml = true
+		case "--toml":
+			cfg.toml = true
+		case "--jq":
+			if i+1 < len(args) {
+				cfg.jq = args[i+1]
+				i++
+			}
+		case "--tree":
+			cfg.tree = true
+		case "--index":
+			cfg.index = true
+		case "--ndjson":
+			cfg.ndjson = true
+		case "--unique":
+			cfg.unique = true
+		case "--unique-by":
+			if i+1 < len(args) {
+				cfg.unique, cfg.uniqueBy = true, args[i+1]
+				i++
+			}
+		case "--group-by":
+			if i+1 < len(args) {
+				cfg.groupBy = args[i+1]
+				i++
+			}
+		case "--facet":
+			if i+1 < len(args) {
+				cfg.facet = args[i+1]
+				i++
+			}
+		case "--csv":
+			cfg.csv = true
+		case "--tsv":
+			cfg.tsv = true
+		case "--tac":
+			cfg.tac = true
+		case "--sort":
+			if i+1 < len(args) {
+				cfg.sortAttr = args[i+1]
+				i++
+			}
+		case "-p", "--pretty":
+			cfg.pretty = true
+		case "--loop":
+			cfg.loop = true
+		case "--exec":
+			if i+1 < len(args) {
+				cfg.execCmd = args[i+1]
+				i++
+			}
+		case "--bidi":
+			cfg.bidi = true
+		case "--preview":
+			if i+1 < len(args) {
+				cfg.preview = args[i+1]
+				i++
+			}
+		case "--vi":
+			cfg.vi = true
+		case "--no-mouse":
+			cfg.noMouse = true
+		case "-f", "--filter":
+			cfg.filterSet = true
+			if i+1 < len(args) {
+				cfg.filter = args[i+1]
+				i++
+			}
+		case "-1", "--select-1":
+			cfg.select1 = true
+		case "-0", "--exit-0":
+			cfg.exit0 = true
+		case "-q", "--query":
+			if i+1 < len(args) {
+				cfg.query = args[i+1]
+				i++
+			}
+		case "--search-all":
+			cfg.searchAll = true
+		case "--regex":
+			cfg.regex = true
+		case "--literal":
+			cfg.literal = true
+		case "--format":
+			if i+1 < len(args) {
+				cfg.format = args[i+1]
+				i++
+			}
+		case "--delimiter":
+			if i+1 < len(args) {
+				cfg.delimiter = args[i+1]
+				i++
+			}
+		case "--height":
+			if i+1 < len(args) {
+				cfg.height = args[i+1]
+				i++
+			}
+		case "--preselect":
+			if i+1 < len(args) {
+				cfg.preselect = append(cfg.preselect, args[i+1])
+				i++
+			}
+		case "--preselect-file":
+			if i+1 < len(args) {
+				values, err := readPreselectFile(args[i+1])
+				if err != nil {
+					fatalError("reading preselect file: %v", err)
+				}
+				cfg.preselect = append(cfg.preselect, values...)
+				i++
+			}
+		case "--info":
+			if i+1 < len(args) {
+				cfg.info = args[i+1]
+				i++
+			}
+		case "--profile":
+			if i+1 < len(args) {
+				profiles++
+				if profiles > maxProfiles {
+					fatalError("too many nested profiles")
+				}
+				profile, err := file.profile(args[i+1])
+				if err == nil {
+					err = checkUniqueArgs(profile, "")
+				}
+				if err != nil {
+					fatalError("%v", err)
+				}
+				// Expand the profile in place, so later options override it
+				args = append(append(append([]string{}, args[:i]...), profile...), args[i+2:]...)
+				i--
+			}
+		case "-h", "--help":
+			outputUsage()
+			os.Exit(0)
+		default:
+			if !strings.HasPrefix(args[i], "-") {
+				if positionals == 0 && isInputFile(args[i], args) {
+					cfg.filename = args[i]
+				} else {
+					// Other positional arguments are display attributes
+					args = append(append(append([]string{}, args[:i]...), "-d"), args[i:]...)
+					i--
+				}
+				positionals++
+			}
+		}
+	}
+
+	cfg.args = args
+	return cfg
+}
+
+// isInputFile reports whether the first positional argument arg names the
+// input file. It does when the file exists, or when there is no other input
+// to read, so that a missing file is reported as such. Otherwise it is a
+// display attribute, as in `kubectl get pods -o json | qjp name`.
+func isInputFile(arg string, args []string) bool {
+	if _, err := os.Stat(arg); err == nil {
+		return true
+	}
+	for _, a := range args {
+		name, _, _ := strings.Cut(a, "=")
+		if name == "--cmd" || name == "--url" {
+			return false
+		}
+	}
+	return !hasStdinInput()
+}
+
+// checkUniqueArgs rejects a bare --unique followed by a word other than the
+// input file, as in --unique id: it drops duplicate objects and takes id as
+// a display attribute, which looks like --unique-by id. args are checked on
+// their own, without the options of other sources around them.
+func checkUniqueArgs(args []string, filename string) error {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] != "--unique" || strings.HasPrefix(args[i+1], "-") || args[i+1] == filename {
+			continue
+		}
+		return fmt.Errorf("--unique %[1]s is ambiguous: use --unique=%[1]s or --unique-by %[1]s to drop the items with the same %[1]s, or put --unique after %[1]s to drop duplicate objects", args[i+1])
+	}
+	return nil
+}
+
+func validateConfig(cfg config) error {
+	if cfg.allAttrs && len(cfg.displayAttrs) > 0 {
+		return fmt.Errorf("cannot use both -a and -d")
+	}
+
+	if cfg.defaultIndex >= 0 && cfg.defaultMatch != "" {
+		return fmt.Errorf("cannot use both --default-index and --default-match")
+	}
+
+	if cfg.defaultMatch != "" && !strings.Contains(cfg.defaultMatch, "=") {
+		return fmt.Errorf("--default-match expects attr=value")
+	}
+
+	if len(cfg.preselect) > 0 && !cfg.lineMode && cfg.key == "" && len(cfg.outputAttrs) != 1 {
+		return fmt.Errorf("--preselect needs --key or a single -o attribute to match values against")
+	}
+
+	if cfg.format != "" && len(cfg.outputAttrs) > 0 {
+		return fmt.Errorf("cannot use both --format and -o")
+	}
+
+	if cfg.format != "" && cfg.printJQPath {
+		return fmt.Errorf("cannot use both --format and --print-jq-path")
+	}
+
+	if cfg.filterSet && cfg.filter == "" {
+		return fmt.Errorf("--filter expects the text to match")
+	}
+
+	if cfg.filter != "" && cfg.query != "" {
+		return fmt.Errorf("cannot use both --filter and --query")
+	}
+
+	if cfg.printJQPath && len(cfg.outputAttrs) > 1 {
+		return fmt.Errorf("cannot use --print-jq-path with several -o attributes")
+	}
+
+	if cfg.printJQPath && cfg.merge {
+		return fmt.Errorf("cannot use both --print-jq-path and --merge")
+	}
+	sources := 0
+	for _, source := range []string{cfg.filename, cfg.inputCmd, cfg.url} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return fmt.Errorf("use only one of filename, --cmd and --url")
+	}
+	if d, err := time.ParseDuration(cfg.timeout); err != nil || d <= 0 {
+		return fmt.Errorf("--timeout expects a duration, e.g. 10s")
+	}
+	if cfg.watch != "" {
+		if d, err := time.ParseDuration(cfg.watch); err != nil || d <= 0 {
+			return fmt.Errorf("--watch expects a duration, e.g. 5s")
+		}
+		if sources == 0 {
+			return fmt.Errorf("--watch needs a filename, --cmd or --url")
+		}
+	}
+	if cfg.execCmd != "" && (cfg.printJQPath || cfg.merge) {
+		return fmt.Errorf("cannot use --exec with --print-jq-path or --merge")
+	}
+
+	switch cfg.preview {
+	case "", "bottom", "right":
+	default:
+		return fmt.Errorf("--preview expects bottom or right")
+	}
+
+	if cfg.height != "" {
+		if _, err := parseHeight(cfg.height, 1); err != nil {
+			return err
+		}
+	}
+
+	switch cfg.info {
+	case "default", "inline", "hidden", "status":
+	default:
+		return fmt.Errorf("--info expects default, inline, hidden or status")
+	}
+
+	formats := 0
+	for _, set := range []bool{cfg.yaml, cfg.toml, cfg.csv, cfg.tsv, cfg.lineMode, cfg.ndjson} {
+		if set {
+			formats++
+		}
+	}
+	if formats > 1 {
+		return fmt.Errorf("use only one of --yaml, --toml, --csv, --tsv, --ndjson and -l")
+	}
+	for _, attr := range append(slices.Clone(cfg.displayAttrs), cfg.outputAttrs...) {
+		if !isJQExpr(attr) {
+			continue
+		}
+		if _, err := compileJQ(attr); err != nil {
+			return fmt.Errorf("invalid jq expression %q: %w", attr, err)
+		}
+	}
+	if cfg.jq != "" {
+		if _, err := compileJQ(cfg.jq); err != nil {
+			return fmt.Errorf("invalid --jq: %w", err)
+		}
+		if cfg.lineMode {
+			return fmt.Errorf("cannot use --jq in line mode")
+		}
+		if cfg.printJQPath {
+			return fmt.Errorf("cannot use --print-jq-path with --jq")
+		}
+	}
+	if cfg.tree {
+		if cfg.lineMode {
+			return fmt.Errorf("cannot use --tree in line mode")
+		}
+		if len(cfg.displayAttrs) > 0 || cfg.allAttrs || len(cfg.columns) > 0 || cfg.tableMode {
+			return fmt.Errorf("cannot use --tree with -d, -a, -T or --column")
+		}
+		if cfg.explode != "" || cfg.merge || cfg.groupBy != "" {
+			return fmt.Errorf("cannot use --tree with --explode, --merge or --group-by")
+		}
+	}
+	if cfg.index {
+		if cfg.filename == "" {
+			return fmt.Errorf("--index needs an NDJSON file")
+		}
+		if len(cfg.displayAttrs) == 0 {
+			return fmt.Errorf("--index needs -d to know which attributes to index")
+		}
+		if cfg.yaml || cfg.toml || cfg.csv || cfg.tsv || cfg.lineMode || cfg.tree || cfg.jq != "" {
+			return fmt.Errorf("cannot use --index with --yaml, --toml, --csv, --tsv, -l, --tree or --jq")
+		}
+		if cfg.explode != "" || len(cfg.columns) > 0 || cfg.recordPath != "" {
+			return fmt.Errorf("cannot use --index with --explode, --column or --record")
+		}
+		for _, attr := range indexAttrs(cfg) {
+			if isJQExpr(attr) {
+				return fmt.Errorf("cannot use jq expressions as attributes with --index")
+			}
+		}
+	}
+	if (cfg.yaml || cfg.toml || cfg.csv || cfg.tsv) && cfg.printJQPath {
+		return fmt.Errorf("cannot use --print-jq-path with --yaml, --toml, --csv or --tsv")
+	}
+
+	if cfg.lineMode {
+		if len(cfg.displayAttrs) > 0 {
+			return fmt.Errorf("cannot use -d in line mode")
+		}
+		if cfg.allAttrs {
+			return fmt.Errorf("cannot use -a in line mode")
+		}
+		if len(cfg.outputAttrs) > 0 {
+			return fmt.Errorf("cannot use -o in line mode")
+		}
+		if cfg.separator != " - " {
+			return fmt.Errorf("cannot use -s in line mode")
+		}
+		if cfg.truncate {
+			return fmt.Errorf("cannot use -t in line mode")
+		}
+		if cfg.tableMode {
+			return fmt.Errorf("cannot use -T in line mode")
+		}
+		if cfg.explode != "" {
+			return fmt.Errorf("cannot use --explode in line mode")
+		}
+		if len(cfg.columns) > 0 {
+			return fmt.Errorf("cannot use --column in line mode")
+		}
+		if cfg.merge {
+			return fmt.Errorf("cannot use --merge in line mode")
+		}
+		if cfg.printJQPath {
+			return fmt.Errorf("cannot use --print-jq-path in line mode")
+		}
+	}
+
+	return nil
+}
+
+// hasStdinInput reports whether the standard input is redirected rather
+// than a terminal.
+func hasStdinInput() bool {
+	stdinStat, _ := os.Stdin.Stat()
+	return (stdinStat.Mode() & os.ModeCharDevice) == 0
+}
+
+func readInput(filename string) ([]byte, error) {
+	hasStdin := hasStdinInput()
+
+	if hasStdin && filename != "" {
+		return nil, fmt.Errorf("cannot use both stdin and filename input")
+	}
+
+	if !hasStdin && filename == "" {
+		return nil, fmt.Errorf("no input provided")
+	}
+
+	var input []byte
+	var err error
+	if filename != "" {
+		input, err = os.ReadFile(filename)
+	} else {
+		input, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if input, err = decompress(input); err != nil && filename != "" {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return input, err
+}
+
+// runInputCommand runs the --cmd command and returns its output. When the
+// command fails, the error includes what it wrote to standard error.
+func runInputCommand(command string) ([]byte, error) {
+	output, err := shellCommand(command).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(bytes.TrimSpace(exitErr.Stderr)) > 0 {
+		return nil, fmt.Errorf("%s: %w: %s", command, err, bytes.TrimSpace(exitErr.Stderr))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", command, err)
+	}
+	return output, nil
+}
+
+// fetchURL returns the body of a GET request to url. Responses other than
+// 2xx are errors, which include the start of the body, where APIs usually
+// explain what went wrong.
+func fetchURL(url string, timeout time.Duration) ([]byte, error) {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", url, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail := truncateWidth(strings.Join(strings.Fields(string(body)), " "), 200)
+		if detail == "" {
+			return nil, fmt.Errorf("%s: %s", url, resp.Status)
+		}
+		return nil, fmt.Errorf("%s: %s: %s", url, resp.Status, detail)
+	}
+	return body, nil
+}
+
+// readSource reads the input named on the command line: the output of
+// --cmd, the body of --url or the input file, decompressed when needed.
+func readSource(cfg config) ([]byte, error) {
+	var input []byte
+	var err error
+	switch {
+	case cfg.inputCmd != "":
+		input, err = runInputCommand(cfg.inputCmd)
+	case cfg.url != "":
+		timeout, _ := time.ParseDuration(cfg.timeout)
+		input, err = fetchURL(cfg.url, timeout)
+	default:
+		input, err = os.ReadFile(cfg.filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decompress(input)
+}
+
+var errNoObjects = errors.New("no objects found in input")
+
+// parseObjects decodes the objects of input in the format given by cfg,
+// along with their text in the input, which is nil in line mode.
+func parseObjects(input []byte, cfg config) ([]map[string]interface{}, []json.RawMessage, error) {
+	var objects []map[string]interface{}
+	var raws []json.RawMessage
+
+	if err := detectFormat(&cfg, input); err != nil {
+		return nil, nil, err
+	}
+	if cfg.yaml || cfg.toml || cfg.csv || cfg.tsv {
+		var err error
+		switch {
+		case cfg.yaml:
+			objects, raws, err = parseYAML(input)
+		case cfg.toml:
+			objects, raws, err = parseTOML(input)
+		case cfg.csv:
+			objects, raws, err = parseCSV(input, ',')
+		default:
+			objects, raws, err = parseCSV(input, '\t')
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	} else if cfg.lineMode {
+		scanner := bufio.NewScanner(bytes.NewReader(input))
+		for scanner.Scan() {
+			objects = append(objects, map[string]interface{}{
+				"line": scanner.Text(),
+			})
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, nil, fmt.Errorf("error reading lines: %w", err)
+		}
+	} else if isJSONLines(input) {
+		var err error
+		objects, raws, err = parseJSONLines(input)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		if err := json.Unmarshal(input, &raws); err != nil {
+			return nil, nil, fmt.Errorf("error parsing JSON: %w", err)
+		}
+		objects = make([]map[string]interface{}, len(raws))
+		for i, raw := range raws {
+			var err error
+			if objects[i], err = decodeElement(raw); err != nil {
+				return nil, nil, fmt.Errorf("error parsing JSON: object %d: %w", i+1, err)
+			}
+		}
+	}
+
+	if len(objects) == 0 {
+		return nil, nil, errNoObjects
+	}
+
+	return objects, raws, nil
+}
+
+// itemPath records where an item comes from in the input document.
+type itemPath struct {
+	object   string          // jq path of the input object
+	exploded string          // attribute the item was exploded from, if any
+	element  int             // index of the element in the exploded attribute
+	raw      json.RawMessage // text of the input object, unless exploded
+	offset   int64           // where the object is in the file with --index
+	size     int             // length of its line there, or 0 without --index
+	value    bool            // whether its value attribute is the value at the path
+}
+
+// String returns the jq path of the item: its input object, or its element
+// when it was exploded.
+func (p itemPath) String() string {
+	if p.exploded == "" {
+		return p.object
+	}
+	return fmt.Sprintf("%s%s[%d]", p.object, jqKey(p.exploded), p.element)
+}
+
+// isJSONLines reports whether input is a stream of objects, such as NDJSON
+// (one object per line), rather than an array.
+func isJSONLines(input []byte) bool {
+	trimmed := bytes.TrimLeft(input, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
+
+// decodeObject decodes a JSON object. Numbers are kept as json.Number, as
+// written in the input, so that large integers such as 64-bit IDs are not
+// rounded through float64.
+func decodeObject(data []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var obj map[string]interface{}
+	err := decoder.Decode(&obj)
+	return obj, err
+}
+
+// elementKey holds the value of an item made from an array element that is
+// not an object. No attribute can name it: such items only have the
+// attribute value, which lookupAttr resolves to it.
+const elementKey = "\x00element"
+
+// decodeElement decodes an element of an array. Objects are items as they
+// are, other elements are kept as items under elementKey.
+func decodeElement(data []byte) (map[string]interface{}, error) {
+	if len(data) > 0 && data[0] == '{' {
+		return decodeObject(data)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{elementKey: value}, nil
+}
+
+// elementValue returns the value of an item made by decodeElement from an
+// element that is not an object.
+func elementValue(obj map[string]interface{}) (interface{}, bool) {
+	v, ok := obj[elementKey]
+	return v, ok
+}
+
+// elementText returns the text shown and output for an item made from an
+// array element that isn't an object: a string as it is, other values as
+// JSON.
+func elementText(obj map[string]interface{}) string {
+	v, _ := elementValue(obj)
+	if s, ok := v.(string); ok {
+		return s
+	}
+	jsonBytes, _ := json.Marshal(v)
+	return string(jsonBytes)
+}
+
+// parseJSONLines decodes a stream of whitespace separated objects.
+func parseJSONLines(input []byte) ([]map[string]interface{}, []json.RawMessage, error) {
+	var objects []map[string]interface{}
+	var raws []json.RawMessage
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	for {
+		var raw json.RawMessage
+		err := decoder.Decode(&raw)
+		if err == io.EOF {
+			return objects, raws, nil
+		}
+		var obj map[string]interface{}
+		if err == nil {
+			obj, err = decodeObject(raw)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing JSON lines: object %d: %w", len(objects)+1, err)
+		}
+		objects = append(objects, obj)
+		raws = append(raws, raw)
+	}
+}
+
+// decodeInput decodes the objects of input as parseObjects does, after
+// running it through --jq. A single object is replaced by its entries, and
+// cfg.entries is set to tell.
+func decodeInput(input []byte, cfg *config) ([]map[string]interface{}, []json.RawMessage, error) {
+	parseCfg := *cfg
+	if cfg.jq != "" {
+		var err error
+		if input, parseCfg, err = runJQ(input, *cfg); err != nil {
+			return nil, nil, err
+		}
+	}
+	objects, raws, err := parseObjects(input, parseCfg)
+	cfg.entries = err == nil && keyedInput(input, parseCfg, len(objects))
+	if cfg.entries {
+		objects, raws, err = objectEntries(raws[0])
+	}
+	return objects, raws, err
+}
+
+// loadItems decodes the items of input for the picker along with where
+// they come from: its prepared objects, or the nodes of its tree with
+// --tree.
+func loadItems(input []byte, cfg *config) ([]map[string]interface{}, []itemPath, error) {
+	if cfg.tree {
+		return treeItems(input, *cfg)
+	}
+	objects, raws, err := decodeInput(input, cfg)
+	objects, paths := prepareObjects(objects, raws, *cfg, 0)
+	return objects, paths, err
+}
+
+// keyedInput tells whether input, which holds count objects, is a single
+// JSON object, whose keys are listed as items rather than the object. NDJSON
+// input, with --ndjson or by its extension, is a record even on one line.
+func keyedInput(input []byte, cfg config, count int) bool {
+	if count != 1 || !isJSONLines(input) || isNDJSON(cfg) {
+		return false
+	}
+	_ = detectFormat(&cfg, input)
+	return !cfg.yaml && !cfg.toml && !cfg.csv && !cfg.tsv && !cfg.lineMode
+}
+
+// objectEntries lists the members of a JSON object in input order as
+// objects holding a key and its value, like jq's to_entries.
+func objectEntries(raw json.RawMessage) ([]map[string]interface{}, []json.RawMessage, error) {
+	var objects []map[string]interface{}
+	var raws []json.RawMessage
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := decoder.Token(); err != nil {
+		return nil, nil, err
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		key, _ := json.Marshal(token.(string))
+		entry := json.RawMessage(fmt.Sprintf(`{"key":%s,"value":%s}`, key, value))
+		obj, err := decodeObject(entry)
+		if err != nil {
+			return nil, nil, err
+		}
+		objects = append(objects, obj)
+		raws = append(raws, entry)
 	}
+	return objects, raws, nil
+}
 
-	if !hasStdin && filename == "" {
-		return nil, fmt.Errorf("no input provided")
+// prepareObjects applies the transformations requested on the command line
+// to freshly parsed objects, the first of which is at index first in the
+// input, and whose text in the input is in raws when known. It also returns
+// where each resulting object comes from in the input document.
+func prepareObjects(objects []map[string]interface{}, raws []json.RawMessage, cfg config, first int) ([]map[string]interface{}, []itemPath) {
+	paths := make([]itemPath, len(objects))
+	for i := range objects {
+		paths[i] = itemPath{object: fmt.Sprintf(".[%d]", first+i)}
+		if cfg.entries {
+			// The path of an entry is that of its value, which is what
+			// path(), del() and |= can work on
+			paths[i] = itemPath{object: memberPath(fmt.Sprint(objects[i]["key"])), value: true}
+		}
+		if i < len(raws) {
+			paths[i].raw = raws[i]
+		}
 	}
-
-	if filename != "" {
-		return os.ReadFile(filename)
+	if cfg.explode != "" {
+		objects, paths = explodeObjects(objects, paths, cfg.explode)
 	}
-
-	return io.ReadAll(os.Stdin)
+	return objects, paths
 }
 
-func parseObjects(input []byte, lineMode bool) ([]map[string]interface{}, error) {
-	var objects []map[string]interface{}
+// explodeObjects replaces every object whose attr is an array with one copy
+// of the object per element, where attr holds that element. Other objects
+// are kept as they are.
+func explodeObjects(objects []map[string]interface{}, paths []itemPath, attr string) ([]map[string]interface{}, []itemPath) {
+	var result []map[string]interface{}
+	var resultPaths []itemPath
+	for i, obj := range objects {
+		elements, ok := obj[attr].([]interface{})
+		if !ok {
+			result = append(result, obj)
+			resultPaths = append(resultPaths, paths[i])
+			continue
+		}
 
-	if lineMode {
-		scanner := bufio.NewScanner(bytes.NewReader(input))
-		for scanner.Scan() {
-			objects = append(objects, map[string]interface{}{
-				"line": scanner.Text(),
-			})
+		for j, element := range elements {
+			exploded := make(map[string]interface{}, len(obj))
+			for k, v := range obj {
+				exploded[k] = v
+			}
+			exploded[attr] = element
+			result = append(result, exploded)
+			resultPaths = append(resultPaths, itemPath{object: paths[i].object, exploded: attr, element: j})
 		}
-		if err := scanner.Err(); err != nil {
-			return nil, fmt.Errorf("error reading lines: %w", err)
+	}
+	return result, resultPaths
+}
+
+// jqAttrPath returns the jq path of attr relative to obj, following the
+// same rules as lookupAttr.
+func jqAttrPath(obj map[string]interface{}, attr string) string {
+	if _, ok := obj[attr]; ok || !isAttrPath(attr) {
+		return jqKey(attr)
+	}
+
+	var path strings.Builder
+	var cur interface{} = obj
+	for _, part := range attrPath(attr) {
+		if arr, ok := cur.([]interface{}); ok {
+			i, _ := strconv.Atoi(part)
+			fmt.Fprintf(&path, "[%d]", i)
+			if i >= 0 && i < len(arr) {
+				cur = arr[i]
+			}
+			continue
 		}
-	} else {
-		if err := json.Unmarshal(input, &objects); err != nil {
-			return nil, fmt.Errorf("error parsing JSON: %w", err)
+		path.WriteString(jqKey(part))
+		if m, ok := cur.(map[string]interface{}); ok {
+			cur = m[part]
 		}
 	}
+	return path.String()
+}
 
-	if len(objects) == 0 {
-		return nil, fmt.Errorf("no objects found in input")
+// memberPath returns the jq path of the member key of the top-level object.
+func memberPath(key string) string {
+	member := jqKey(key)
+	if member[0] == '[' {
+		return "." + member
 	}
+	return member
+}
 
-	return objects, nil
+// jqKeyPattern matches keys that jq accepts in the .key shorthand.
+var jqKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// jqKey returns the jq path component selecting key.
+func jqKey(key string) string {
+	if jqKeyPattern.MatchString(key) {
+		return "." + key
+	}
+	quoted, _ := json.Marshal(key)
+	return fmt.Sprintf("[%s]", quoted)
 }
 
 func getAllAttributes(objects []map[string]interface{}) []string {
 	attrMap := make(map[string]bool)
 	for _, obj := range objects {
 		for key := range obj {
-			attrMap[key] = true
+			if key != elementKey {
+				attrMap[key] = true
+			}
 		}
 	}
 
@@ -661,13 +3177,36 @@ func getAllAttributes(objects []map[string]interface{}) []string {
 	return attrs
 }
 
+// parseFormat parses a --format template. Besides the builtin functions,
+// templates can use json to output a value as single-line JSON.
+func parseFormat(text string) (*template.Template, error) {
+	funcs := template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			jsonBytes, err := json.Marshal(v)
+			return string(jsonBytes), err
+		},
+	}
+	// A missing attribute is an error, as with -o
+	return template.New("format").Funcs(funcs).Option("missingkey=error").Parse(text)
+}
+
+// executeFormat renders obj through the --format template.
+func (a *App) executeFormat(obj map[string]interface{}) (string, error) {
+	var out strings.Builder
+	if err := a.format.Execute(&out, obj); err != nil {
+		return "", fmt.Errorf("error formatting output: %w", err)
+	}
+	return out.String(), nil
+}
+
 func formatOutputValue(val interface{}) (string, error) {
 	switch v := val.(type) {
+	case json.Number:
+		return v.String(), nil
 	case float64:
-		if v == float64(int64(v)) {
-			return fmt.Sprintf("%d", int64(v)), nil
-		}
-		return fmt.Sprintf("%v", v), nil
+		// Without an exponent, as computed columns such as price*qty
+		// would otherwise show 1.5e+06
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
 	case string:
 		return v, nil
 	case []interface{}, map[string]interface{}:
@@ -681,83 +3220,658 @@ func formatOutputValue(val interface{}) (string, error) {
 	}
 }
 
-func outputSelectedObjects(objects []map[string]interface{}, indices []int, outputAttr string) error {
+// formatOutput formats an output attribute value, colorizing arrays and
+// objects when printing to a terminal, and indenting them with --pretty.
+func (a *App) formatOutput(val interface{}) (string, error) {
+	switch val.(type) {
+	case []interface{}, map[string]interface{}:
+		if a.colorOutput {
+			colored, err := colorizeJSON(val)
+			if err != nil {
+				return "", fmt.Errorf("error marshaling output: %w", err)
+			}
+			return colored, nil
+		}
+		if a.pretty {
+			jsonBytes, err := json.MarshalIndent(val, "", "  ")
+			if err != nil {
+				return "", fmt.Errorf("error marshaling output: %w", err)
+			}
+			return string(jsonBytes), nil
+		}
+	}
+	return formatOutputValue(val)
+}
+
+func (a *App) outputSelectedObjects(indices []int) error {
+	if a.printJQPath {
+		for _, idx := range indices {
+			fmt.Println(a.jqPath(idx))
+		}
+		return nil
+	}
+
+	if a.jqMode && a.jqCode != nil {
+		return a.outputJQResults(indices)
+	}
+
+	if a.merge {
+		// Items selected later override the earlier ones
+		merged := map[string]interface{}{}
+		for _, idx := range a.selectionOrder(indices) {
+			obj := a.object(idx)
+			if _, ok := elementValue(obj); !ok {
+				mergeObjects(merged, obj)
+			}
+		}
+		return a.outputObject(merged, nil)
+	}
+
 	for _, idx := range indices {
-		selectedObj := objects[idx]
+		if err := a.outputObject(a.object(idx), a.rawObject(idx)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deliver outputs the selected items, or runs the --exec command on them.
+func (a *App) deliver(indices []int) error {
+	if a.execCmd != "" {
+		return a.execSelection(indices)
+	}
+	return a.outputSelectedObjects(indices)
+}
+
+// finish delivers the final selection. When a command fails, qjp exits
+// with its exit status.
+func (a *App) finish(indices []int) {
+	err := a.deliver(indices)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	if err != nil {
+		fatalError("%v", err)
+	}
+}
+
+// deliverAndContinue delivers the selection in --loop mode and clears it
+// for the next pick. The terminal is handed back while commands run or
+// output goes to it; errors are shown next to the filter.
+func (a *App) deliverAndContinue(indices []int) {
+	if len(indices) == 0 {
+		return
+	}
+	suspended := a.execCmd != "" || term.IsTerminal(int(os.Stdout.Fd()))
+	if suspended {
+		a.suspend()
+	}
+	err := a.deliver(indices)
+	if suspended {
+		a.resume()
+	}
+	if err != nil {
+		a.message = err.Error()
+	}
+	a.selected = make(map[int]int)
+}
+
+// jqPath returns the jq path of an object in the input document, or of its
+// output attribute when one is given.
+func (a *App) jqPath(idx int) string {
+	path := a.paths[idx]
+	if _, ok := elementValue(a.objects[idx]); ok {
+		path.value = true
+	}
+	if a.outputAttr == "" || a.outputAttr == path.exploded || (path.value && a.outputAttr == "value") {
+		return path.String()
+	}
+	for _, col := range a.columns {
+		if col.name == a.outputAttr {
+			return path.String()
+		}
+	}
+	if isJQExpr(a.outputAttr) {
+		return path.String() + " | " + a.outputAttr
+	}
+	obj := a.object(idx)
+	if _, ok := obj[a.outputAttr]; !ok && isGJSONPath(a.outputAttr) {
+		return path.String()
+	}
+	attrPath := jqAttrPath(obj, a.outputAttr)
+	if path.value {
+		// The item is a key and its value, and the path is the value's:
+		// attributes below value are below the path, and key has none
+		rest, ok := strings.CutPrefix(attrPath, ".value")
+		if !ok || (rest != "" && rest[0] != '.' && rest[0] != '[') {
+			return path.String()
+		}
+		return path.object + rest
+	}
+	return path.object + attrPath
+}
+
+// rawObject returns the text of the object at idx in the input, or nil when
+// it is not known, as for exploded items and in line mode. With --index, it
+// is read from the input file.
+func (a *App) rawObject(idx int) json.RawMessage {
+	if idx >= len(a.paths) {
+		return nil
+	}
+	if path := a.paths[idx]; path.size > 0 {
+		raw, err := a.readIndexed(path)
+		if err != nil {
+			a.message = fmt.Sprintf("reading the input file: %v", err)
+			return nil
+		}
+		return raw
+	}
+	return a.paths[idx].raw
+}
+
+// objectJSON returns obj as single-line JSON. When its text in the input is
+// known, obj is written as in the input, minus the whitespace.
+func objectJSON(obj map[string]interface{}, raw json.RawMessage) ([]byte, error) {
+	if v, ok := elementValue(obj); ok && raw == nil {
+		return json.Marshal(v)
+	}
+	if raw == nil {
+		return json.Marshal(obj)
+	}
+	var buf bytes.Buffer
+	err := json.Compact(&buf, raw)
+	return buf.Bytes(), err
+}
 
-		if outputAttr != "" {
-			val, ok := selectedObj[outputAttr]
+// prettyJSON is objectJSON indented over several lines.
+func prettyJSON(obj map[string]interface{}, raw json.RawMessage) ([]byte, error) {
+	if v, ok := elementValue(obj); ok && raw == nil {
+		return json.MarshalIndent(v, "", "  ")
+	}
+	if raw == nil {
+		return json.MarshalIndent(obj, "", "  ")
+	}
+	var buf bytes.Buffer
+	err := json.Indent(&buf, raw, "", "  ")
+	return buf.Bytes(), err
+}
+
+// outputObject prints selectedObj, whose text in the input is raw when
+// known, as the --format text, its output attributes or the whole object.
+func (a *App) outputObject(selectedObj map[string]interface{}, raw json.RawMessage) error {
+	if a.format != nil {
+		formatted, err := a.executeFormat(selectedObj)
+		if err != nil {
+			return err
+		}
+		fmt.Println(formatted)
+	} else if len(a.outputAttrs) > 1 {
+		values := make([]string, len(a.outputAttrs))
+		for i, attr := range a.outputAttrs {
+			val, ok := a.attrValue(selectedObj, attr)
 			if !ok {
-				return fmt.Errorf("attribute '%s' not found in selected object", outputAttr)
+				return fmt.Errorf("attribute '%s' not found in selected object", attr)
 			}
-
-			formatted, err := formatOutputValue(val)
+			formatted, err := a.formatOutput(val)
 			if err != nil {
 				return err
 			}
-			fmt.Println(formatted)
+			values[i] = formatted
+		}
+		fmt.Println(strings.Join(values, a.delimiter))
+	} else if a.outputAttr != "" {
+		val, ok := a.attrValue(selectedObj, a.outputAttr)
+		if !ok {
+			return fmt.Errorf("attribute '%s' not found in selected object", a.outputAttr)
+		}
+
+		formatted, err := a.formatOutput(val)
+		if err != nil {
+			return err
+		}
+		fmt.Println(formatted)
+	} else if v, ok := elementValue(selectedObj); ok && isString(v) {
+		// Strings are output unquoted, as with -o value
+		fmt.Println(v)
+	} else if a.colorOutput {
+		var colored string
+		var err error
+		if raw != nil {
+			colored, err = colorizeRaw(raw)
 		} else {
-			jsonBytes, err := json.Marshal(selectedObj)
-			if err != nil {
-				return fmt.Errorf("error marshaling output: %w", err)
-			}
-			fmt.Println(string(jsonBytes))
+			colored, err = colorizeJSON(selectedObj)
+		}
+		if err != nil {
+			return fmt.Errorf("error marshaling output: %w", err)
+		}
+		fmt.Println(colored)
+	} else {
+		var jsonBytes []byte
+		var err error
+		if a.pretty {
+			jsonBytes, err = prettyJSON(selectedObj, raw)
+		} else {
+			jsonBytes, err = objectJSON(selectedObj, raw)
 		}
+		if err != nil {
+			return fmt.Errorf("error marshaling output: %w", err)
+		}
+		fmt.Println(string(jsonBytes))
 	}
 
 	return nil
 }
 
+// isString tells whether val is a JSON string.
+func isString(val interface{}) bool {
+	_, ok := val.(string)
+	return ok
+}
+
+// mergeObjects deep-merges src into dst: nested objects are merged key by
+// key, any other value in src replaces the one in dst. src is not modified.
+func mergeObjects(dst, src map[string]interface{}) {
+	for key, val := range src {
+		srcObj, ok := val.(map[string]interface{})
+		if !ok {
+			dst[key] = val
+			continue
+		}
+		dstObj, ok := dst[key].(map[string]interface{})
+		if !ok {
+			dstObj = map[string]interface{}{}
+			dst[key] = dstObj
+		}
+		mergeObjects(dstObj, srcObj)
+	}
+}
+
 func fatalError(format string, args ...interface{}) {
 	fmt.Fprintf(os.Stderr, "Error: "+format+"\n", args...)
 	os.Exit(1)
 }
 
 func main() {
-	cfg := parseArgs()
+	file, err := loadConfigFile()
+	if err != nil {
+		fatalError("%v", err)
+	}
+	if err := file.applyColors(); err != nil {
+		fatalError("%v", err)
+	}
+	defaults := file.Defaults
+	envDefaults, err := splitShellWords(os.Getenv("QJP_DEFAULT_OPTS"))
+	if err != nil {
+		fatalError("QJP_DEFAULT_OPTS: %v", err)
+	}
+	for _, args := range [][]string{defaults, envDefaults} {
+		if err := checkUniqueArgs(args, ""); err != nil {
+			fatalError("%v", err)
+		}
+	}
+	defaults = append(defaults, envDefaults...)
+	cfg := parseArgs(append(defaults, os.Args[1:]...), file)
+	if err := checkUniqueArgs(os.Args[1:], cfg.filename); err != nil {
+		fatalError("%v", err)
+	}
+
+	var replay *session
+	if cfg.replayPath != "" {
+		replay, err = loadSession(cfg.replayPath)
+		if err != nil {
+			fatalError("%v", err)
+		}
+		cfg = parseArgs(replay.header.Args, file)
+	}
 
 	if err := validateConfig(cfg); err != nil {
-		fatalError(err.Error())
+		fatalError("%v", err)
 	}
 
-	input, err := readInput(cfg.filename)
-	if err != nil {
-		if err.Error() == "no input provided" {
-			outputUsage()
+	bindings := defaultBindings()
+	if cfg.multi {
+		bindings["tab"] = action{name: "toggle"}
+	}
+	if cfg.vi {
+		bindings["esc"] = action{name: "normal-mode"}
+	}
+	for _, spec := range cfg.bindings {
+		if err := parseBindings(spec, bindings); err != nil {
+			fatalError("%v", err)
 		}
-		fatalError(err.Error())
 	}
 
-	objects, err := parseObjects(input, cfg.lineMode)
-	if err != nil {
-		fatalError(err.Error())
+	var format *template.Template
+	if cfg.format != "" {
+		var err error
+		format, err = parseFormat(cfg.format)
+		if err != nil {
+			fatalError("invalid --format: %v", err)
+		}
+	}
+
+	var columns []computedColumn
+	for _, spec := range cfg.columns {
+		col, err := parseColumn(spec)
+		if err != nil {
+			fatalError("%v", err)
+		}
+		columns = append(columns, col)
+	}
+
+	var input []byte
+	var objects []map[string]interface{}
+	var paths []itemPath
+	var stream *inputStream
+	var indexFile *os.File
+	var unique *uniqueFilter
+	if cfg.unique {
+		unique = newUniqueFilter(cfg.uniqueBy)
+	}
+	// --select-1, --exit-0 and --filter depend on the whole input
+	streaming := !cfg.select1 && !cfg.exit0 && cfg.filter == ""
+	if streaming && replay == nil && cfg.filename == "" && cfg.inputCmd == "" && cfg.url == "" && !cfg.yaml && !cfg.toml && !cfg.csv && !cfg.tsv && cfg.jq == "" && !cfg.tree && cfg.recordPath == "" && hasStdinInput() {
+		// Start as soon as there is something to show, and take the rest
+		// of the input while the picker runs
+		stdin, err := decompressReader(os.Stdin)
+		if err != nil {
+			fatalError("%v", err)
+		}
+		stream = streamInput(stdin, cfg)
+		for len(objects) == 0 {
+			first := stream.read
+			item, ok := stream.next()
+			if !ok {
+				if stream.err != nil {
+					fatalError("%v", stream.err)
+				}
+				fatalError("%v", errNoObjects)
+			}
+			cfg.entries = stream.entries
+			objects, paths = prepareObjects([]map[string]interface{}{item.obj}, []json.RawMessage{item.raw}, cfg, first)
+			if unique != nil {
+				objects, paths = unique.apply(objects, paths)
+			}
+		}
+	} else {
+		if cfg.index {
+			indexFile, objects, paths, err = openIndex(cfg)
+			if err != nil {
+				fatalError("%s: %v", cfg.filename, err)
+			}
+		} else if replay != nil {
+			input = replay.header.Input
+			cfg.filename, cfg.inputCmd, cfg.url = "", "", ""
+		} else if cfg.inputCmd != "" || cfg.url != "" {
+			input, err = readSource(cfg)
+			if err != nil {
+				fatalError("%v", err)
+			}
+		} else {
+			input, err = readInput(cfg.filename)
+			if err != nil {
+				if err.Error() == "no input provided" {
+					outputUsage()
+				}
+				fatalError("%v", err)
+			}
+		}
+
+		if !cfg.index {
+			objects, paths, err = loadItems(input, &cfg)
+		}
+		if err != nil && !errors.Is(err, errNoObjects) {
+			if cfg.filename != "" {
+				err = fmt.Errorf("%s: %w", cfg.filename, err)
+			}
+			fatalError("%v", err)
+		}
+		if unique != nil {
+			objects, paths = unique.apply(objects, paths)
+		}
+		if len(objects) == 0 {
+			if cfg.exit0 || cfg.filter != "" {
+				os.Exit(1)
+			}
+			fatalError("%v", errNoObjects)
+		}
 	}
 
 	displayAttrs := cfg.displayAttrs
-	outputAttr := cfg.outputAttr
+	var outputAttr string
+	if len(cfg.outputAttrs) > 0 {
+		outputAttr = cfg.outputAttrs[0]
+	}
 
 	if cfg.lineMode {
 		displayAttrs = []string{"line"}
 		outputAttr = "line"
+	} else if cfg.entries && len(displayAttrs) == 0 && !cfg.allAttrs && len(columns) == 0 {
+		displayAttrs = []string{"key", "value"}
+	}
+	if cfg.tree && len(cfg.outputAttrs) == 0 {
+		outputAttr = "value"
 	} else if cfg.allAttrs {
 		displayAttrs = getAllAttributes(objects)
 	}
+	for _, col := range columns {
+		displayAttrs = append(displayAttrs, col.name)
+	}
+
+	// The terminal is opened once it is known to be needed, so that
+	// --select-1 and --exit-0 also work without one
+	app := newApp(objects, displayAttrs, outputAttr, nil, cfg.truncate, cfg.tableMode, cfg.separator)
+	app.key = cfg.key
+	app.outputAttrs = cfg.outputAttrs
+	app.delimiter = cfg.delimiter
+	app.format = format
+	app.regex = cfg.regex
+	app.literal = cfg.literal
+	app.searchAll = cfg.searchAll
+	app.mouse = !cfg.noMouse
+	if cfg.vi {
+		app.normalKeys = normalModeBindings()
+	}
+	app.bindings = bindings
+	app.linkField = cfg.linkField
+	app.columns = columns
+	app.keepOutput = cfg.keepOutput
+	app.info = cfg.info
+	app.merge = cfg.merge
+	app.paths = paths
+	app.indexFile = indexFile
+	app.printJQPath = cfg.printJQPath
+	app.execCmd = cfg.execCmd
+	app.loop = cfg.loop
+	app.pretty = cfg.pretty
+	app.bidi = cfg.bidi
+	app.multi = cfg.multi
+	app.unique = unique
+	if cfg.groupBy != "" {
+		app.groupBy = cfg.groupBy
+		app.collapsed = make(map[string]bool)
+		app.updateFilter()
+	}
+	if cfg.facet != "" {
+		app.facetAttr = cfg.facet
+		app.openFacets(cfg.facet)
+	}
+	if cfg.tree {
+		// The root starts expanded
+		app.treeMode = true
+		app.treeOpen = map[string]bool{".": true}
+		app.updateFilter()
+	}
+	if cfg.preview != "" {
+		app.showPreview = true
+		app.previewSide = cfg.preview
+	}
+	app.colorOutput = term.IsTerminal(int(os.Stdout.Fd())) && os.Getenv("NO_COLOR") == ""
+	app.colLimits = cfg.colLimits
+	if cfg.tableMode && (len(columns) > 0 || len(cfg.colLimits) > 0) {
+		app.calculateColumnWidths()
+	}
+	if cfg.index {
+		app.load = func() ([]map[string]interface{}, []itemPath, error) {
+			file, objects, paths, err := openIndex(cfg)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", cfg.filename, err)
+			}
+			if unique != nil {
+				unique.reset()
+				objects, paths = unique.apply(objects, paths)
+			}
+			app.indexFile.Close()
+			app.indexFile = file
+			return objects, paths, nil
+		}
+	} else if cfg.filename != "" || cfg.inputCmd != "" || cfg.url != "" {
+		app.load = func() ([]map[string]interface{}, []itemPath, error) {
+			input, err := readSource(cfg)
+			if err != nil {
+				return nil, nil, err
+			}
+			loaded := cfg
+			objects, paths, err := loadItems(input, &loaded)
+			if err == nil && loaded.entries != cfg.entries {
+				err = errors.New("the input changed between a single object and a list of objects")
+			}
+			if err != nil && cfg.filename != "" {
+				return nil, nil, fmt.Errorf("%s: %w", cfg.filename, err)
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			if unique != nil {
+				unique.reset()
+				objects, paths = unique.apply(objects, paths)
+			}
+			return objects, paths, nil
+		}
+	}
+	if cfg.watch != "" {
+		app.watch, _ = time.ParseDuration(cfg.watch)
+	}
+	if cfg.tac {
+		app.tac = true
+		app.updateFilter()
+	}
+	if cfg.sortAttr != "" {
+		app.sortBy, app.sortDesc = strings.CutPrefix(cfg.sortAttr, "-")
+		// A display attribute becomes the sort column, so that F3 goes on
+		// from there
+		if i := slices.Index(displayAttrs, app.sortBy); i >= 0 {
+			app.sortColumn, app.sortBy = i, ""
+		}
+		app.updateFilter()
+	}
+	if cfg.query != "" {
+		app.filter = cfg.query
+		app.updateFilter()
+	}
+	if !app.setInitialCursor(cfg.defaultIndex, cfg.defaultMatch) && stream != nil {
+		// The item may be yet to come
+		app.defaultIndex, app.defaultMatch = cfg.defaultIndex, cfg.defaultMatch
+	}
+	if len(cfg.preselect) > 0 {
+		app.preselectBy = cfg.key
+		if app.preselectBy == "" {
+			app.preselectBy = outputAttr
+		}
+		app.preselected = cfg.preselect
+		app.preselect(0)
+	}
+	if stream != nil {
+		app.stream = stream
+		app.allAttrs = cfg.allAttrs && !cfg.lineMode
+		app.prepare = func(objects []map[string]interface{}, raws []json.RawMessage, first int) ([]map[string]interface{}, []itemPath) {
+			objects, paths := prepareObjects(objects, raws, cfg, first)
+			if unique != nil {
+				objects, paths = unique.apply(objects, paths)
+			}
+			return objects, paths
+		}
+	}
+
+	if cfg.filter != "" {
+		// Filter without the UI, like fzf --filter
+		app.filter = cfg.filter
+		app.updateFilter()
+		if app.filterErr != "" {
+			fatalError("%s", app.filterErr)
+		}
+		if len(app.filtered) == 0 {
+			os.Exit(1)
+		}
+		app.finish(app.filtered)
+		return
+	}
+	if cfg.exit0 && len(app.filtered) == 0 {
+		os.Exit(1)
+	}
+	if cfg.select1 && len(app.filtered) == 1 {
+		app.finish(app.filtered)
+		return
+	}
 
-	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	tty, ttyOut, err := openTerminal()
 	if err != nil {
-		fatalError("opening /dev/tty: %v", err)
+		fatalError("%v", err)
 	}
 	defer tty.Close()
+	if ttyOut != tty {
+		defer ttyOut.Close()
+	}
+
+	if err := enableVirtualTerminal(ttyOut); err != nil {
+		fatalError("console does not support ANSI escape sequences: %v", err)
+	}
+
+	app.tty, app.ttyOut, app.out = tty, ttyOut, ttyOut
+	app.resize()
+	if cfg.height != "" {
+		app.inlineHeight = cfg.height
+		app.height, _ = parseHeight(cfg.height, app.height)
+	}
+
+	if replay != nil {
+		app.width, app.height = replay.header.Width, replay.header.Height
+		app.replay = replay.events
+	}
+
+	if cfg.recordPath != "" {
+		header := sessionHeader{
+			Args:   withoutOption(cfg.args, "--record"),
+			Width:  app.width,
+			Height: app.height,
+			Input:  input,
+		}
+		app.recorder, err = startRecording(cfg.recordPath, header)
+		if err != nil {
+			fatalError("recording session: %v", err)
+		}
+	}
+
+	if cfg.controlPath != "" {
+		app.control, err = listenControl(cfg.controlPath)
+		if err != nil {
+			app.closeSession()
+			fatalError("opening control socket: %v", err)
+		}
+		app.controlPath = cfg.controlPath
+		app.commands = make(chan controlCommand)
+		go serveControl(app.control, app.commands)
+	}
 
-	app := newApp(objects, displayAttrs, outputAttr, tty, cfg.truncate, cfg.tableMode, cfg.separator)
 	selectedIndices, err := app.run()
+	app.closeSession()
 	if err != nil {
 		fatalError("%v", err)
 	}
 
 	if len(selectedIndices) > 0 {
-		if err := outputSelectedObjects(app.objects, selectedIndices, outputAttr); err != nil {
-			fatalError("%v", err)
-		}
+		app.finish(selectedIndices)
 	}
 }
@@ -0,0 +1,280 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/itchyny/gojq"
+)
+
+// mixedArrays are arrays mixing objects with other values, in both orders.
+var mixedArrays = []struct {
+	name    string
+	input   string
+	objects []bool // whether each element is an object
+}{
+	{"value first", `["a",{"x":1},3]`, []bool{false, true, false}},
+	{"object first", `[{"x":1},"a"]`, []bool{true, false}},
+}
+
+func TestParseObjectsMixedArray(t *testing.T) {
+	for _, tt := range mixedArrays {
+		t.Run(tt.name, func(t *testing.T) {
+			objects, _, err := parseObjects([]byte(tt.input), config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkMixedItems(t, objects, tt.objects)
+		})
+	}
+}
+
+func TestSingleLineNDJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config
+		entries bool
+	}{
+		{"json", config{filename: "item.json"}, true},
+		{"ndjson extension", config{filename: "item.ndjson"}, false},
+		{"jsonl extension", config{filename: "item.JSONL.gz"}, false},
+		{"ndjson flag", config{ndjson: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects, _, err := decodeInput([]byte(`{"id":1,"name":"a"}`+"\n"), &tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := 1
+			if tt.entries {
+				want = 2
+			}
+			if tt.cfg.entries != tt.entries || len(objects) != want {
+				t.Errorf("got %d items, entries %v; want %d, %v", len(objects), tt.cfg.entries, want, tt.entries)
+			}
+		})
+	}
+}
+
+// checkMixedItems checks that the items made from the objects of a mixed
+// array have their attributes, and that the others only have value.
+func checkMixedItems(t *testing.T, objects []map[string]interface{}, isObject []bool) {
+	t.Helper()
+	if len(objects) != len(isObject) {
+		t.Fatalf("got %d items, want %d", len(objects), len(isObject))
+	}
+	for i, obj := range objects {
+		x, hasX := lookupAttr(obj, "x")
+		_, hasValue := lookupAttr(obj, "value")
+		if isObject[i] {
+			if !hasX || formatValue(t, x) != "1" || hasValue {
+				t.Errorf("item %d: x = %v, %v; has value = %v; want the object", i, x, hasX, hasValue)
+			}
+		} else if hasX || !hasValue {
+			t.Errorf("item %d: has x = %v, has value = %v; want only value", i, hasX, hasValue)
+		}
+	}
+
+	want := "a"
+	if isObject[0] {
+		want = `{"x":1}`
+	}
+	app := &App{objects: objects}
+	if got := app.getDisplayValue(objects[0]); got != want {
+		t.Errorf("first item shows %q, want %q", got, want)
+	}
+}
+
+func formatValue(t *testing.T, val interface{}) string {
+	t.Helper()
+	s, err := formatOutputValue(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// fuzzParseObjects checks that parseObjects, reading input with the format
+// set by cfg, fails rather than crash or hang on malformed input.
+func fuzzParseObjects(f *testing.F, cfg config, seeds []string) {
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, input []byte) {
+		objects, raws, err := parseObjects(input, cfg)
+		if err != nil {
+			return
+		}
+		if len(objects) == 0 || (raws != nil && len(raws) != len(objects)) {
+			t.Fatalf("got %d objects and %d raws", len(objects), len(raws))
+		}
+	})
+}
+
+func FuzzParseJSON(f *testing.F) {
+	fuzzParseObjects(f, config{}, []string{
+		`[{"a":1},{"a":2}]`, `["a",{"x":1},3]`, `[]`, `[[1,2],null]`, `{"key":"value"}`,
+		`[{"n":12345678901234567890}]`, `[{"a":`, `[1,]`, ` [ "é" ] `,
+	})
+}
+
+func FuzzParseNDJSON(f *testing.F) {
+	fuzzParseObjects(f, config{}, []string{
+		"{\"a\":1}\n{\"a\":2}\n", "{\"a\":1}{\"b\":2}", "{\"a\":1}\n[1]\n", "{\"a\":1}\n{",
+	})
+}
+
+func FuzzParseYAML(f *testing.F) {
+	fuzzParseObjects(f, config{yaml: true}, []string{
+		"- a: 1\n- a: 2\n", "a: 1\n---\nb: 2\n", "base: &b\n  x: 1\nother:\n  <<: *b\n",
+		"- [1, 2]\n", "t: 2024-01-01T00:00:00Z\n", "a: *missing\n", "- - -\n",
+	})
+}
+
+func FuzzParseTOML(f *testing.F) {
+	fuzzParseObjects(f, config{toml: true}, []string{
+		"[[hosts]]\nname = \"a\"\n[[hosts]]\nname = \"b\"\n", "title = \"x\"\n[owner]\nname = \"y\"\n",
+		"a.b.c = 1\n", "[[a]]\n[[a.b]]\n", "x = [1, \"a\"]\n", "[table\n",
+	})
+}
+
+func FuzzParseCSV(f *testing.F) {
+	fuzzParseObjects(f, config{csv: true}, []string{
+		"name,age\nann,3\nbob,4\n", "a,b\n1\n", "\"quoted, field\",b\n1,2\n", "a,a\n1,2\n", "\"unterminated\n",
+	})
+}
+
+func TestParseArgsUnique(t *testing.T) {
+	tests := []struct {
+		args     []string
+		uniqueBy string
+	}{
+		{[]string{"--unique"}, ""},
+		{[]string{"name", "--unique"}, ""},
+		{[]string{"--unique=id"}, "id"},
+		{[]string{"--unique-by", "id"}, "id"},
+		{[]string{"--unique-by=meta.id"}, "meta.id"},
+	}
+	for _, tt := range tests {
+		cfg := parseArgs(tt.args, configFile{})
+		if !cfg.unique || cfg.uniqueBy != tt.uniqueBy {
+			t.Errorf("parseArgs(%q) = unique %v by %q; want by %q", tt.args, cfg.unique, cfg.uniqueBy, tt.uniqueBy)
+		}
+	}
+}
+
+func TestCheckUniqueArgs(t *testing.T) {
+	tests := []struct {
+		args     []string
+		filename string
+		ok       bool
+	}{
+		{[]string{"--unique"}, "", true},
+		{[]string{"--unique", "events.json"}, "events.json", true},
+		{[]string{"events.json", "id", "--unique"}, "events.json", true},
+		{[]string{"--unique", "-d", "id"}, "", true},
+		{[]string{"--unique=id", "name"}, "", true},
+		{[]string{"--unique-by", "id"}, "", true},
+		{[]string{"--unique", "id"}, "", false},
+		{[]string{"events.json", "--unique", "id"}, "events.json", false},
+	}
+	for _, tt := range tests {
+		if err := checkUniqueArgs(tt.args, tt.filename); (err == nil) != tt.ok {
+			t.Errorf("checkUniqueArgs(%q) = %v, want ok %v", tt.args, err, tt.ok)
+		}
+	}
+}
+
+func TestMergeFollowsSelectionOrder(t *testing.T) {
+	objects := []map[string]interface{}{{"port": 80}, {"port": 443}, {"port": 8080}}
+	app := newApp(objects, nil, "", nil, false, false, " - ")
+	for _, cursor := range []int{2, 0, 1} {
+		app.cursor = cursor
+		app.toggleSelection()
+	}
+	if got := app.getSelection(); !slices.Equal(got, []int{0, 1, 2}) {
+		t.Errorf("getSelection() = %v, want input order", got)
+	}
+	if got := app.selectionOrder(app.getSelection()); !slices.Equal(got, []int{2, 0, 1}) {
+		t.Errorf("selectionOrder() = %v, want [2 0 1]", got)
+	}
+}
+
+func TestFilterNeedsText(t *testing.T) {
+	for _, args := range [][]string{{"-f", ""}, {"--filter="}, {"-f"}} {
+		if err := validateConfig(parseArgs(args, configFile{})); err == nil {
+			t.Errorf("validateConfig(%q) accepted an empty filter", args)
+		}
+	}
+	if err := validateConfig(parseArgs([]string{"-f", "web"}, configFile{})); err != nil {
+		t.Errorf("validateConfig(-f web): %v", err)
+	}
+}
+
+func TestJQPathOfEntries(t *testing.T) {
+	input := []byte(`{"web":{"port":80,"tags":["a"]},"a b":{"port":443}}`)
+	cfg := config{}
+	objects, paths, err := loadItems(input, &cfg)
+	if err != nil || !cfg.entries {
+		t.Fatalf("loadItems() = %d items, entries %v, %v", len(objects), cfg.entries, err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(input, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		idx    int
+		output string
+		want   string
+	}{
+		{0, "", ".web"},
+		{0, "value", ".web"},
+		{0, "value.port", ".web.port"},
+		{0, "value.tags.0", ".web.tags[0]"},
+		{0, "key", ".web"},
+		{1, "", `.["a b"]`},
+		{1, "value.port", `.["a b"].port`},
+	}
+	for _, tt := range tests {
+		app := newApp(objects, nil, tt.output, nil, false, false, " - ")
+		app.paths = paths
+		got := app.jqPath(tt.idx)
+		if got != tt.want {
+			t.Errorf("jqPath(%d) with -o %q = %s, want %s", tt.idx, tt.output, got, tt.want)
+			continue
+		}
+		query, err := gojq.Parse("path(" + got + ")")
+		if err != nil {
+			t.Errorf("jq rejects %s: %v", got, err)
+			continue
+		}
+		if v, ok := query.Run(doc).Next(); !ok {
+			t.Errorf("path(%s) returned nothing", got)
+		} else if err, ok := v.(error); ok {
+			t.Errorf("path(%s): %v", got, err)
+		}
+	}
+}
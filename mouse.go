@@ -0,0 +1,125 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Report button presses and the wheel, in the SGR encoding
+	mouseOn  = "\033[?1000h\033[?1006h"
+	mouseOff = "\033[?1006l\033[?1000l"
+)
+
+// doubleClickInterval is the longest time between the clicks of a
+// double-click.
+const doubleClickInterval = 500 * time.Millisecond
+
+// mouseKeys are the mouse events that can be used with --bind.
+var mouseKeys = []string{"left-click", "double-click", "scroll-up", "scroll-down"}
+
+// mouseEvent is a decoded mouse report.
+type mouseEvent struct {
+	button   int
+	row, col int // 1-based
+	press    bool
+}
+
+// parseMouse decodes an SGR mouse report, ESC [ < button ; col ; row M for
+// a press or m for a release.
+func parseMouse(seq string) (mouseEvent, bool) {
+	if !strings.HasPrefix(seq, "\x1b[<") || len(seq) < 4 {
+		return mouseEvent{}, false
+	}
+	final := seq[len(seq)-1]
+	fields := strings.Split(seq[3:len(seq)-1], ";")
+	if len(fields) != 3 || (final != 'M' && final != 'm') {
+		return mouseEvent{}, false
+	}
+
+	var values [3]int
+	for i, field := range fields {
+		v, err := strconv.Atoi(field)
+		if err != nil {
+			return mouseEvent{}, false
+		}
+		values[i] = v
+	}
+	// Ignore the shift, alt and ctrl modifiers
+	button := values[0] &^ (4 | 8 | 16)
+	return mouseEvent{button: button, col: values[1], row: values[2], press: final == 'M'}, true
+}
+
+// handleMouse moves the cursor to a clicked item and runs the actions bound
+// to mouse events. It returns done when an action ends the session.
+func (a *App) handleMouse(ev mouseEvent) (done bool, result []int) {
+	if !ev.press || a.popup != nil {
+		return false, nil
+	}
+
+	var key string
+	switch ev.button {
+	case 64:
+		key = "scroll-up"
+	case 65:
+		key = "scroll-down"
+	case 0:
+		if a.headerHeight() > 0 && ev.row == a.listTop-1 {
+			// Clicking a column name sorts by it
+			if i := a.columnAt(ev.col); i >= 0 && ev.col <= a.listWidth() {
+				a.sortByColumn(i)
+			}
+			return false, nil
+		}
+		item, ok := a.itemAt(ev.row, ev.col)
+		if !ok {
+			return false, nil
+		}
+		a.cursor = item
+		key = "left-click"
+		now := time.Now()
+		if item == a.clickedItem && now.Sub(a.lastClick) < doubleClickInterval {
+			key = "double-click"
+			now = time.Time{}
+		}
+		a.lastClick, a.clickedItem = now, item
+	default:
+		return false, nil
+	}
+
+	if act, ok := a.bindings[key]; ok {
+		return a.runAction(act)
+	}
+	return false, nil
+}
+
+// itemAt returns the position in the filtered list of the item drawn at a
+// screen position, using the rows recorded by the last frame.
+func (a *App) itemAt(row, col int) (int, bool) {
+	i := row - a.listTop
+	if i < 0 || i >= len(a.itemRows) || col > a.listWidth() {
+		return 0, false
+	}
+	return a.itemRows[i], true
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMouse(t *testing.T) {
+	tests := []struct {
+		seq  string
+		want mouseEvent
+		ok   bool
+	}{
+		{"\x1b[<0;12;5M", mouseEvent{button: 0, col: 12, row: 5, press: true}, true},
+		{"\x1b[<0;12;5m", mouseEvent{button: 0, col: 12, row: 5}, true},
+		{"\x1b[<64;1;1M", mouseEvent{button: 64, col: 1, row: 1, press: true}, true},
+		{"\x1b[<65;80;24M", mouseEvent{button: 65, col: 80, row: 24, press: true}, true},
+		{"\x1b[<16;3;4M", mouseEvent{button: 0, col: 3, row: 4, press: true}, true},
+		{"\x1b[<72;3;4M", mouseEvent{button: 64, col: 3, row: 4, press: true}, true},
+		{"\x1b[<0;12M", mouseEvent{}, false},
+		{"\x1b[<0;12;5;1M", mouseEvent{}, false},
+		{"\x1b[<0;x;5M", mouseEvent{}, false},
+		{"\x1b[<0;12;5~", mouseEvent{}, false},
+		{"\x1b[<", mouseEvent{}, false},
+		{"\x1b[A", mouseEvent{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseMouse(tt.seq)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseMouse(%q) = %+v, %v; want %+v, %v", tt.seq, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func FuzzParseMouse(f *testing.F) {
+	for _, seed := range []string{
+		"\x1b[<0;12;5M", "\x1b[<0;12;5m", "\x1b[<64;1;1M", "\x1b[<65;80;24M",
+		"\x1b[<", "\x1b[<M", "\x1b[<;;M", "\x1b[<1;2M", "\x1b[<1;2;3;4M",
+		"\x1b[<-1;2;3M", "\x1b[<99999999999999999999;1;1M", "\x1b[A",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, seq string) {
+		ev, ok := parseMouse(seq)
+		if ok && (!strings.HasPrefix(seq, "\x1b[<") || ev.press != strings.HasSuffix(seq, "M")) {
+			t.Fatalf("parseMouse(%q) = %+v", seq, ev)
+		}
+	})
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// valuePopup shows how often each value of an attribute occurs among the
+// filtered items, and lets the user filter by one of them.
+type valuePopup struct {
+	attrs  []string
+	attr   int
+	values []valueCount
+	cursor int
+}
+
+type valueCount struct {
+	value string
+	count int
+}
+
+// popupBarWidth is the width of the histogram bar of the most frequent value.
+const popupBarWidth = 20
+
+// openValuePopup opens the popup for the display attributes, or for every
+// attribute when whole objects are displayed.
+func (a *App) openValuePopup() {
+	attrs := a.displayAttrs
+	if len(attrs) == 0 {
+		attrs = getAllAttributes(a.objects)
+	}
+	if len(attrs) == 0 {
+		return
+	}
+
+	a.popup = &valuePopup{attrs: attrs}
+	a.countValues()
+}
+
+// countValues fills the popup with the values of the chosen attribute in the
+// filtered items, most frequent first.
+func (a *App) countValues() {
+	p := a.popup
+	attr := p.attrs[p.attr]
+
+	counts := make(map[string]int)
+	for _, idx := range a.filtered {
+		val, ok := a.attrValue(a.objects[idx], attr)
+		if !ok {
+			continue
+		}
+		formatted, err := formatOutputValue(val)
+		if err != nil {
+			continue
+		}
+		counts[formatted]++
+	}
+
+	p.values = p.values[:0]
+	for value, count := range counts {
+		p.values = append(p.values, valueCount{value: value, count: count})
+	}
+	sort.Slice(p.values, func(i, j int) bool {
+		if p.values[i].count != p.values[j].count {
+			return p.values[i].count > p.values[j].count
+		}
+		return p.values[i].value < p.values[j].value
+	})
+	p.cursor = 0
+}
+
+// handlePopupKey handles a key while the popup is open.
+func (a *App) handlePopupKey(key string) {
+	p := a.popup
+	switch key {
+	case "up":
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case "down":
+		if p.cursor < len(p.values)-1 {
+			p.cursor++
+		}
+	case "tab", "right":
+		p.attr = (p.attr + 1) % len(p.attrs)
+		a.countValues()
+	case "btab", "left":
+		p.attr = (p.attr + len(p.attrs) - 1) % len(p.attrs)
+		a.countValues()
+	case "enter":
+		if p.cursor < len(p.values) {
+			a.filter = p.values[p.cursor].value
+			a.updateFilter()
+		}
+		a.popup = nil
+	case "esc", "ctrl-c", "f2":
+		a.popup = nil
+	}
+}
+
+func (p *valuePopup) render(frame *bytes.Buffer, lines, width int) {
+	// The keys are left out rather than wrapped on narrow screens
+	title := "Values of " + p.attrs[p.attr]
+	keys := " (Tab: next attribute, Enter: filter, Esc: close)"
+	if displayWidth(title+keys) >= width {
+		title, keys = truncateWidth(title, width-1), ""
+	}
+	fmt.Fprintf(frame, "%s%s%s%s\r\n", colorInfo, title, colorReset, keys)
+	lines--
+
+	if len(p.values) == 0 {
+		fmt.Fprint(frame, "  (no values)\r\n")
+		return
+	}
+
+	valueWidth := 0
+	for _, v := range p.values {
+		valueWidth = max(valueWidth, displayWidth(v.value))
+	}
+	valueWidth = min(valueWidth, max(width/2, 10))
+
+	start := 0
+	if p.cursor >= lines {
+		start = p.cursor - lines + 1
+	}
+	maxCount := p.values[0].count
+	for i := start; i < len(p.values) && i < start+lines; i++ {
+		v := p.values[i]
+		value := truncateWidth(v.value, valueWidth)
+		value += strings.Repeat(" ", valueWidth-displayWidth(value))
+		bar := strings.Repeat("█", max(1, v.count*popupBarWidth/maxCount))
+		row := truncateWidth(fmt.Sprintf("%s  %5d %s", value, v.count, bar), width-3)
+		if i == p.cursor {
+			fmt.Fprintf(frame, "%s> %s%s\r\n", colorReverse, row, colorReset)
+		} else {
+			fmt.Fprintf(frame, "  %s\r\n", row)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// kindInput holds items with an attribute taking the same values, and one
+// without it.
+const kindInput = `[
+	{"name": "a", "kind": "x"},
+	{"name": "b", "kind": "y"},
+	{"name": "c", "kind": "x"},
+	{"name": "d"},
+	{"name": "e", "kind": "y"}
+]`
+
+// newLoadedApp sets the picker up for input as main does without options.
+func newLoadedApp(t *testing.T, input string, displayAttrs ...string) *App {
+	t.Helper()
+	cfg := parseArgs(nil, configFile{})
+	objects, paths, err := loadItems([]byte(input), &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := newApp(objects, displayAttrs, "", nil, false, false, cfg.separator)
+	app.paths = paths
+	return app
+}
+
+// listedNames returns the names of the listed items.
+func listedNames(app *App) []string {
+	var names []string
+	for _, idx := range app.filtered {
+		names = append(names, app.objects[idx]["name"].(string))
+	}
+	return names
+}
+
+func TestValuePopup(t *testing.T) {
+	app := newLoadedApp(t, kindInput, "name", "kind")
+	app.filter = "!c"
+	app.updateFilter()
+	app.openValuePopup()
+	if app.popup == nil {
+		t.Fatal("openValuePopup() left the popup closed")
+	}
+
+	app.handlePopupKey("tab")
+	want := []valueCount{{"y", 2}, {"x", 1}}
+	if got := app.popup.values; !slices.Equal(got, want) {
+		t.Errorf("values of kind %v among the matches, want %v", got, want)
+	}
+	app.handlePopupKey("left")
+	app.handlePopupKey("left")
+	if got := app.popup.attrs[app.popup.attr]; got != "kind" {
+		t.Errorf("left twice moved to %s, want kind", got)
+	}
+
+	app.handlePopupKey("down")
+	app.handlePopupKey("down")
+	app.handlePopupKey("enter")
+	if app.popup != nil || app.filter != "x" {
+		t.Errorf("enter left the filter %q, want x and the popup closed", app.filter)
+	}
+	if got := listedNames(app); !slices.Equal(got, []string{"a", "c"}) {
+		t.Errorf("listed %q", got)
+	}
+
+	app.openValuePopup()
+	app.handlePopupKey("esc")
+	if app.popup != nil || app.filter != "x" {
+		t.Error("esc changed the filter or left the popup open")
+	}
+}
@@ -0,0 +1,698 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// queryTerm is a term of a query, matching the display value of the items,
+// or the value of an attribute with field:value.
+type queryTerm struct {
+	field  string // attribute matched, or empty for the display value
+	text   string
+	match  termMatch
+	negate bool // whether the items matching the term are excluded instead
+
+	compare string  // comparison operator of field>number terms
+	number  float64 // number the attribute is compared to
+}
+
+// compareOps are the operators of comparison terms, the two character ones
+// first so that <= isn't taken for <.
+var compareOps = []string{"<=", ">=", "!=", "<", ">", "="}
+
+// termMatch is the way a query term matches a value.
+type termMatch int
+
+const (
+	matchContains termMatch = iota // text anywhere in the value
+	matchPrefix                    // ^text
+	matchSuffix                    // text$
+	matchExact                     // ^text$
+)
+
+// queryNode is a node of a parsed query: a term, or the and, or or not of
+// other nodes.
+type queryNode struct {
+	op    string // "and", "or" or "not", or empty for a term
+	nodes []*queryNode
+	term  queryTerm
+}
+
+// parseQuery parses query into the expression its items must match, or
+// returns nil when every item does. Terms are separated by spaces, so that
+// prod api eu matches "eu-west production api gateway", and a phrase with
+// spaces is written in double quotes. Terms are written as in fzf: 'text
+// matches text literally, ^text at the start, text$ at the end, and !term
+// excludes what term matches. field:value terms, for fields that are
+// attributes of some item, match the value of the attribute, which can be
+// quoted to hold spaces, as in name:"api gateway". field>number terms,
+// with <, <=, >, >=, = or !=, compare the value of the attribute as a
+// number. has:field and missing:field match the items with and without the
+// attribute.
+//
+// Terms next to each other must all match, and can be combined with and,
+// or, not and parentheses, as in (status:failed or status:error) and
+// region:eu.
+func (a *App) parseQuery(query string) *queryNode {
+	p := queryParser{app: a, tokens: splitQuery(query)}
+	return p.parseOr()
+}
+
+// queryParser parses the tokens of a query. It takes incomplete queries,
+// as they are while being typed, leaving out what is missing: an or or a
+// not with nothing after it, or the closing parentheses.
+type queryParser struct {
+	app    *App
+	tokens []string
+	pos    int
+}
+
+// peek returns the next token, or an empty string at the end.
+func (p *queryParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// parseOr parses terms joined by or.
+func (p *queryParser) parseOr() *queryNode {
+	var nodes []*queryNode
+	for {
+		if node := p.parseAnd(); node != nil {
+			nodes = append(nodes, node)
+		}
+		if p.peek() != "or" {
+			return joinNodes("or", nodes)
+		}
+		p.pos++
+	}
+}
+
+// parseAnd parses terms next to each other or joined by and, up to an or
+// or the end of a group.
+func (p *queryParser) parseAnd() *queryNode {
+	var nodes []*queryNode
+	for p.pos < len(p.tokens) {
+		switch p.peek() {
+		case "or", ")":
+			return joinNodes("and", nodes)
+		case "and":
+			p.pos++
+			continue
+		}
+		if node := p.parseNot(); node != nil {
+			nodes = append(nodes, node)
+		}
+	}
+	return joinNodes("and", nodes)
+}
+
+// parseNot parses a term, a group in parentheses, or either after not.
+func (p *queryParser) parseNot() *queryNode {
+	token := p.peek()
+	p.pos++
+	switch token {
+	case "not":
+		if p.peek() == "" {
+			return nil
+		}
+		node := p.parseNot()
+		if node == nil {
+			return nil
+		}
+		return &queryNode{op: "not", nodes: []*queryNode{node}}
+	case "(":
+		node := p.parseOr()
+		if p.peek() == ")" {
+			p.pos++
+		}
+		return node
+	}
+
+	term, ok := p.app.parseTerm(token)
+	if !ok {
+		return &queryNode{term: queryTerm{text: unquoteTerm(token)}}
+	}
+	if term.text == "" && (term.field == "" || term.compare != "") {
+		// An operator with nothing after it yet
+		return nil
+	}
+	return &queryNode{term: term}
+}
+
+// joinNodes returns the node joining nodes with op, or the only one of
+// them.
+func joinNodes(op string, nodes []*queryNode) *queryNode {
+	switch len(nodes) {
+	case 0:
+		return nil
+	case 1:
+		return nodes[0]
+	}
+	return &queryNode{op: op, nodes: nodes}
+}
+
+// parseTerm parses token as a query term. It reports false for plain text,
+// which has neither a field nor an operator.
+func (a *App) parseTerm(token string) (queryTerm, bool) {
+	var term queryTerm
+	text := token
+	if strings.HasPrefix(text, "!") {
+		term.negate = true
+		text = text[1:]
+	}
+	if term, ok := a.parseComparison(term, text); ok {
+		return term, true
+	}
+	if term, ok := a.parseExistence(term, text); ok {
+		return term, true
+	}
+	if field, value, ok := strings.Cut(text, ":"); ok && field != "" && a.hasAttr(field) {
+		term.field = field
+		text = value
+	}
+
+	// The operators go around the quotes of a phrase, as in ^"api gateway"
+	switch {
+	case strings.HasPrefix(text, "'"):
+		text = text[1:]
+	case strings.HasPrefix(text, "^") && strings.HasSuffix(text, "$") && len(text) > 1:
+		term.match = matchExact
+		text = text[1 : len(text)-1]
+	case strings.HasPrefix(text, "^"):
+		term.match = matchPrefix
+		text = text[1:]
+	case strings.HasSuffix(text, "$"):
+		term.match = matchSuffix
+		text = text[:len(text)-1]
+	case !term.negate && term.field == "":
+		return term, false
+	}
+	term.text = unquoteTerm(text)
+	return term, true
+}
+
+// parseComparison parses text as a field>number term, or any other
+// comparison operator. It reports false when field isn't an attribute of
+// any item or the number isn't one. A term without its number yet, as in
+// price>, has an empty text and matches every item.
+func (a *App) parseComparison(term queryTerm, text string) (queryTerm, bool) {
+	i := strings.IndexAny(text, "<>=!")
+	if i <= 0 || !a.hasAttr(text[:i]) {
+		return term, false
+	}
+	for _, op := range compareOps {
+		value, ok := strings.CutPrefix(text[i:], op)
+		if !ok {
+			continue
+		}
+		term.field, term.compare = text[:i], op
+		if value == "" {
+			return term, true
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return term, false
+		}
+		term.text, term.number = value, n
+		return term, true
+	}
+	return term, false
+}
+
+// parseExistence parses text as a has:field or missing:field term, which
+// is a field term with no value, negated for missing. It reports false
+// when some item has an attribute named has or missing, which the term
+// is then matched against.
+func (a *App) parseExistence(term queryTerm, text string) (queryTerm, bool) {
+	keyword, field, ok := strings.Cut(text, ":")
+	if !ok || (keyword != "has" && keyword != "missing") || a.hasAttr(keyword) {
+		return term, false
+	}
+	term.field = field
+	if keyword == "missing" {
+		term.negate = !term.negate
+	}
+	return term, true
+}
+
+// splitQuery splits query into tokens at spaces outside double quotes.
+func splitQuery(query string) []string {
+	var tokens []string
+	var token strings.Builder
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			token.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if token.Len() > 0 {
+				tokens = append(tokens, token.String())
+				token.Reset()
+			}
+		default:
+			token.WriteRune(r)
+		}
+	}
+	if token.Len() > 0 {
+		tokens = append(tokens, token.String())
+	}
+	return splitParens(tokens)
+}
+
+// splitParens splits the parentheses opening and closing groups off
+// tokens. A closing parenthesis is only split off while a group is open,
+// so that words such as f(x) are left whole.
+func splitParens(tokens []string) []string {
+	var split []string
+	depth := 0
+	for _, token := range tokens {
+		for strings.HasPrefix(token, "(") {
+			split = append(split, "(")
+			token = token[1:]
+			depth++
+		}
+		closing := 0
+		for closing < depth && strings.HasSuffix(token, ")") {
+			token = token[:len(token)-1]
+			closing++
+		}
+		if token == ")" {
+			// Closing no group, it is the text )
+			token = "')"
+		}
+		if token != "" {
+			split = append(split, token)
+		}
+		for ; closing > 0; closing-- {
+			split = append(split, ")")
+			depth--
+		}
+	}
+	return split
+}
+
+// unquoteTerm removes the double quotes around the value of a term, also
+// while the closing quote is yet to be typed.
+func unquoteTerm(value string) string {
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		return unquoted
+	}
+	if strings.HasPrefix(value, `"`) {
+		return strings.TrimSuffix(value[1:], `"`)
+	}
+	return value
+}
+
+// colorQuery returns query as typed, with the operators of its terms in
+// color, and the first problem found in it: a comparison with something
+// that isn't a number or a phrase missing its closing quote, which are
+// matched as plain text, or a group missing its closing parenthesis. The
+// query is split as splitQuery does, keeping the spaces.
+func (a *App) colorQuery(query string) (string, string) {
+	var b strings.Builder
+	var problem string
+	depth := 0
+	for query != "" {
+		start := strings.IndexFunc(query, func(r rune) bool { return !unicode.IsSpace(r) })
+		if start < 0 {
+			b.WriteString(query)
+			break
+		}
+		b.WriteString(query[:start])
+		query = query[start:]
+		end := tokenEnd(query)
+		token := query[:end]
+		query = query[end:]
+
+		for strings.HasPrefix(token, "(") {
+			b.WriteString(colorOp("("))
+			token = token[1:]
+			depth++
+		}
+		closing := 0
+		for closing < depth && strings.HasSuffix(token, ")") {
+			token = token[:len(token)-1]
+			closing++
+		}
+		colored, tokenProblem := a.colorTerm(token)
+		b.WriteString(colored)
+		if problem == "" {
+			problem = tokenProblem
+		}
+		b.WriteString(strings.Repeat(colorOp(")"), closing))
+		depth -= closing
+	}
+	if problem == "" && depth > 0 {
+		problem = "missing )"
+	}
+	return b.String(), problem
+}
+
+// colorTerm returns token, a term of a query, with its operators in color,
+// as parseTerm finds them, and the problem found in it, if any.
+func (a *App) colorTerm(token string) (string, string) {
+	switch token {
+	case "and", "or", "not":
+		return colorOp(token), ""
+	}
+	var problem string
+	if strings.Count(token, `"`)%2 == 1 {
+		problem = "missing closing quote"
+	}
+
+	var b strings.Builder
+	text := token
+	if strings.HasPrefix(text, "!") {
+		b.WriteString(colorOp("!"))
+		text = text[1:]
+	}
+	if i := strings.IndexAny(text, "<>=!"); i > 0 && a.hasAttr(text[:i]) {
+		for _, op := range compareOps {
+			value, ok := strings.CutPrefix(text[i:], op)
+			if !ok {
+				continue
+			}
+			if _, err := strconv.ParseFloat(value, 64); err != nil && value != "" {
+				return token, fmt.Sprintf("%s isn't a number", value)
+			}
+			b.WriteString(text[:i] + colorOp(op) + value)
+			return b.String(), ""
+		}
+	}
+	if keyword, field, ok := strings.Cut(text, ":"); ok && (keyword == "has" || keyword == "missing") && !a.hasAttr(keyword) {
+		b.WriteString(colorOp(keyword+":") + field)
+		return b.String(), problem
+	}
+	if field, value, ok := strings.Cut(text, ":"); ok && field != "" && a.hasAttr(field) {
+		b.WriteString(colorOp(field + ":"))
+		text = value
+	}
+
+	switch {
+	case strings.HasPrefix(text, "'"):
+		b.WriteString(colorOp("'") + text[1:])
+	case strings.HasPrefix(text, "^") && strings.HasSuffix(text, "$") && len(text) > 1:
+		b.WriteString(colorOp("^") + text[1:len(text)-1] + colorOp("$"))
+	case strings.HasPrefix(text, "^"):
+		b.WriteString(colorOp("^") + text[1:])
+	case strings.HasSuffix(text, "$"):
+		b.WriteString(text[:len(text)-1] + colorOp("$"))
+	default:
+		b.WriteString(text)
+	}
+	return b.String(), problem
+}
+
+// tokenEnd returns the length of the token query starts with, up to a
+// space outside double quotes.
+func tokenEnd(query string) int {
+	quoted := false
+	for i, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case unicode.IsSpace(r) && !quoted:
+			return i
+		}
+	}
+	return len(query)
+}
+
+// colorOp returns op, an operator of a query, in color.
+func colorOp(op string) string {
+	return colorInfo + op + colorReset
+}
+
+// attrIndex is the set of attributes the items have, which the fields of
+// query terms are looked up in while typing and rendering, rather than
+// going through every item each time.
+type attrIndex struct {
+	known   map[string]bool // attributes some item has
+	missing map[string]bool // attributes no item has, until more arrive
+}
+
+// add records the keys of objects, and the dot separated paths to the keys
+// of their nested objects, as known attributes. Attributes no item had may
+// be found in the new objects, so they are forgotten.
+func (x *attrIndex) add(objects []map[string]interface{}) {
+	var walk func(prefix string, obj map[string]interface{})
+	walk = func(prefix string, obj map[string]interface{}) {
+		for key, value := range obj {
+			if key == elementKey {
+				x.known["value"] = true
+				continue
+			}
+			if prefix == "" && isJQExpr(key) {
+				// Such an attribute is evaluated as jq
+				continue
+			}
+			x.known[prefix+key] = true
+			if nested, ok := value.(map[string]interface{}); ok {
+				walk(prefix+key+".", nested)
+			}
+		}
+	}
+	for _, obj := range objects {
+		walk("", obj)
+	}
+	clear(x.missing)
+}
+
+// hasAttr tells whether some item has the attribute attr. Other attributes
+// than keys and paths through objects, such as array indices, computed
+// columns, JSON Pointers, gjson paths and jq expressions, are evaluated on
+// the items once and remembered until the items change.
+func (a *App) hasAttr(attr string) bool {
+	if a.attrs == nil {
+		a.attrs = &attrIndex{known: make(map[string]bool), missing: make(map[string]bool)}
+		a.attrs.add(a.objects)
+	}
+	if a.attrs.known[attr] {
+		return true
+	}
+	if a.attrs.missing[attr] {
+		return false
+	}
+	for _, obj := range a.objects {
+		if _, ok := a.attrValue(obj, attr); ok {
+			a.attrs.known[attr] = true
+			return true
+		}
+	}
+	a.attrs.missing[attr] = true
+	return false
+}
+
+// compileItemQuery returns a function reporting whether an item matches
+// query, as parsed by parseQuery. In regex mode, the whole query is a
+// regular expression matched as compileQuery does.
+func (a *App) compileItemQuery(query string) (func(map[string]interface{}) bool, error) {
+	if a.regex {
+		textMatch, err := a.compileQuery(query)
+		if err != nil {
+			return nil, err
+		}
+		return func(obj map[string]interface{}) bool {
+			return textMatch(a.searchText(obj))
+		}, nil
+	}
+
+	node := a.parseQuery(query)
+	return func(obj map[string]interface{}) bool {
+		return node == nil || a.matchNode(node, obj)
+	}, nil
+}
+
+// matchNode tells whether obj matches node.
+func (a *App) matchNode(node *queryNode, obj map[string]interface{}) bool {
+	switch node.op {
+	case "and":
+		for _, n := range node.nodes {
+			if !a.matchNode(n, obj) {
+				return false
+			}
+		}
+		return true
+	case "or":
+		for _, n := range node.nodes {
+			if a.matchNode(n, obj) {
+				return true
+			}
+		}
+		return false
+	case "not":
+		return !a.matchNode(node.nodes[0], obj)
+	}
+	return a.matchTerm(node.term, obj) != node.term.negate
+}
+
+// highlightTerms returns the text of the terms of node that match the
+// display value of the items, leaving out those that exclude items.
+func highlightTerms(node *queryNode, negated bool) []string {
+	if node == nil {
+		return nil
+	}
+	if node.op == "" {
+		if node.term.field != "" || node.term.negate != negated || node.term.text == "" {
+			return nil
+		}
+		return []string{node.term.text}
+	}
+	var texts []string
+	for _, n := range node.nodes {
+		texts = append(texts, highlightTerms(n, negated != (node.op == "not"))...)
+	}
+	return texts
+}
+
+// matchTerm tells whether obj matches term, leaving its negation aside.
+// A field term doesn't match items without the attribute.
+func (a *App) matchTerm(term queryTerm, obj map[string]interface{}) bool {
+	var value string
+	if term.field == "" {
+		value = a.searchText(obj)
+	} else {
+		val, ok := a.attrValue(obj, term.field)
+		if !ok {
+			return false
+		}
+		if term.compare != "" {
+			return compareTerm(term, val)
+		}
+		formatted, err := formatOutputValue(val)
+		if err != nil {
+			return false
+		}
+		value = formatted
+	}
+
+	fold := a.ignoreCase(term.text)
+	value, text := a.foldText(value, fold), a.foldText(term.text, fold)
+	switch term.match {
+	case matchPrefix:
+		return strings.HasPrefix(value, text)
+	case matchSuffix:
+		return strings.HasSuffix(value, text)
+	case matchExact:
+		return value == text
+	}
+	return strings.Contains(value, text)
+}
+
+// compareTerm tells whether val, as a number, compares to the number of
+// term as its operator requires. Values that aren't numbers never do.
+func compareTerm(term queryTerm, val interface{}) bool {
+	n, ok := toNumber(val)
+	if !ok {
+		return false
+	}
+	switch term.compare {
+	case "<":
+		return n < term.number
+	case "<=":
+		return n <= term.number
+	case ">":
+		return n > term.number
+	case ">=":
+		return n >= term.number
+	case "!=":
+		return n != term.number
+	}
+	return n == term.number
+}
+
+// caseMode is the way queries match the case of letters.
+type caseMode int
+
+const (
+	caseSmart  caseMode = iota // ignoring case unless the query has uppercase
+	caseIgnore                 // ignoring case
+	caseMatch                  // matching case
+)
+
+// toggleCase switches from smart case to ignoring case, to matching case,
+// and back to smart case.
+func (a *App) toggleCase() {
+	a.caseMode = (a.caseMode + 1) % 3
+	a.computeBaseItems()
+	a.updateFilter()
+}
+
+// ignoreCase tells whether text is matched ignoring case. With smart case,
+// as in fzf and ripgrep, it is unless text holds an uppercase letter.
+func (a *App) ignoreCase(text string) bool {
+	switch a.caseMode {
+	case caseIgnore:
+		return true
+	case caseMatch:
+		return false
+	}
+	for _, r := range text {
+		if unicode.IsUpper(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// regexIgnoresCase tells whether the regular expression pattern is matched
+// ignoring case, as ignoreCase does. With smart case, the uppercase letters
+// of escapes, such as \S or \p{Greek}, don't count.
+func (a *App) regexIgnoresCase(pattern string) bool {
+	if a.caseMode != caseSmart {
+		return a.caseMode == caseIgnore
+	}
+	escaped := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case escaped:
+			escaped = false
+			if (c == 'p' || c == 'P') && i+1 < len(pattern) {
+				if pattern[i+1] != '{' {
+					i++
+				} else if end := strings.IndexByte(pattern[i:], '}'); end >= 0 {
+					i += end
+				}
+			}
+		case c == '\\':
+			escaped = true
+		case c >= 'A' && c <= 'Z':
+			return false
+		case c >= 0x80:
+			r, size := utf8.DecodeRuneInString(pattern[i:])
+			if unicode.IsUpper(r) {
+				return false
+			}
+			i += size - 1
+		}
+	}
+	return true
+}
@@ -0,0 +1,269 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseTermQuotedPhrase(t *testing.T) {
+	app := &App{objects: []map[string]interface{}{{"name": "api gateway"}}}
+	tests := []struct {
+		token string
+		want  queryTerm
+	}{
+		{`!"api gateway"`, queryTerm{text: "api gateway", negate: true}},
+		{`^"api gateway"`, queryTerm{text: "api gateway", match: matchPrefix}},
+		{`"api gateway"$`, queryTerm{text: "api gateway", match: matchSuffix}},
+		{`^"api gateway"$`, queryTerm{text: "api gateway", match: matchExact}},
+		{`'"api gateway"`, queryTerm{text: "api gateway"}},
+		{`!^"api gateway"`, queryTerm{text: "api gateway", match: matchPrefix, negate: true}},
+		{`name:^"api gateway"$`, queryTerm{field: "name", text: "api gateway", match: matchExact}},
+		{`!name:"api gateway"`, queryTerm{field: "name", text: "api gateway", negate: true}},
+		{`^"api gate`, queryTerm{text: "api gate", match: matchPrefix}},
+	}
+	for _, tt := range tests {
+		got, ok := app.parseTerm(tt.token)
+		if !ok || got != tt.want {
+			t.Errorf("parseTerm(%s) = %+v, %v; want %+v", tt.token, got, ok, tt.want)
+		}
+	}
+}
+
+func TestQuotedPhraseOperatorsMatch(t *testing.T) {
+	app := &App{objects: []map[string]interface{}{{"name": "api gateway"}, {"name": "gateway api"}}}
+	app.displayAttrs = []string{"name"}
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{`"api gateway"`, []int{0}},
+		{`!"api gateway"`, []int{1}},
+		{`^"api gateway"`, []int{0}},
+		{`^"gateway api"$`, []int{1}},
+		{`"way api"$`, []int{1}},
+		{`!^"api"`, []int{1}},
+	}
+	for _, tt := range tests {
+		got, err := app.matchItems([]int{0, 1}, tt.query)
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("matchItems(%s) = %v, %v; want %v", tt.query, got, err, tt.want)
+		}
+	}
+}
+
+func TestColorQuery(t *testing.T) {
+	app := &App{objects: []map[string]interface{}{{"name": "api", "price": 120}}}
+	c := colorOp
+	tests := []struct {
+		query   string
+		want    string
+		problem string
+	}{
+		{"prod api", "prod api", ""},
+		{"^web .json$ !db", c("^") + "web .json" + c("$") + " " + c("!") + "db", ""},
+		{`'^x ^"api gateway"$`, c("'") + `^x ` + c("^") + `"api gateway"` + c("$"), ""},
+		{"name:api price>=100", c("name:") + "api price" + c(">=") + "100", ""},
+		{"has:owner missing:tags", c("has:") + "owner " + c("missing:") + "tags", ""},
+		{"(a or b) and not c", c("(") + "a " + c("or") + " b" + c(")") + " " + c("and") + " " + c("not") + " c", ""},
+		{"12:30 f(x)", "12:30 f(x)", ""},
+		{"price>abc", "price>abc", "abc isn't a number"},
+		{`name:"api gate`, c("name:") + `"api gate`, "missing closing quote"},
+		{"(a or b", c("(") + "a " + c("or") + " b", "missing )"},
+	}
+	for _, tt := range tests {
+		got, problem := app.colorQuery(tt.query)
+		if got != tt.want || problem != tt.problem {
+			t.Errorf("colorQuery(%s) = %q, %q; want %q, %q", tt.query, got, problem, tt.want, tt.problem)
+		}
+	}
+}
+
+// describeNode writes node as nested lists, with the operators of its terms
+// where the query had them, so that parse trees read like the query.
+func describeNode(node *queryNode) string {
+	if node == nil {
+		return "<nil>"
+	}
+	if node.op == "" {
+		t := node.term
+		s := t.text
+		switch t.match {
+		case matchPrefix:
+			s = "^" + s
+		case matchSuffix:
+			s += "$"
+		case matchExact:
+			s = "^" + s + "$"
+		}
+		if t.compare != "" {
+			s = t.compare + s
+		} else if t.field != "" {
+			s = ":" + s
+		}
+		s = t.field + s
+		if t.negate {
+			s = "!" + s
+		}
+		return s
+	}
+	parts := make([]string, len(node.nodes))
+	for i, n := range node.nodes {
+		parts[i] = describeNode(n)
+	}
+	return fmt.Sprintf("(%s %s)", node.op, strings.Join(parts, " "))
+}
+
+func TestParseQuery(t *testing.T) {
+	app := &App{objects: []map[string]interface{}{{"name": "api", "price": 120, "status": "ok"}}}
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "<nil>"},
+		{"   ", "<nil>"},
+		{"api", "api"},
+		{"prod api eu", "(and prod api eu)"},
+		{"a and b", "(and a b)"},
+		{"a or b c", "(or a (and b c))"},
+		{"not a", "(not a)"},
+		{"!a", "!a"},
+		{"^web .json$ 'x", "(and ^web .json$ x)"},
+		{`^"api gateway"$`, "^api gateway$"},
+		{"name:api", "name:api"},
+		{"other:api", "other:api"},
+		{"price>=100 price!=120", "(and price>=100 price!=120)"},
+		{"price>abc", "price>abc"},
+		{"has:owner", "owner:"},
+		{"missing:owner", "!owner:"},
+		{"(status:failed or status:error) and name:eu", "(and (or status:failed status:error) name:eu)"},
+		{"not (a or b)", "(not (or a b))"},
+		{"(a or b", "(or a b)"},
+		{"a or", "a"},
+		{"a not", "a"},
+		{"price>", "<nil>"},
+		{"^", "<nil>"},
+	}
+	for _, tt := range tests {
+		if got := describeNode(app.parseQuery(tt.query)); got != tt.want {
+			t.Errorf("parseQuery(%q) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTerm(t *testing.T) {
+	obj := map[string]interface{}{"name": "API Gateway", "price": 120.0, "tags": []interface{}{"eu"}, "note": "n/a"}
+	app := &App{objects: []map[string]interface{}{obj}, displayAttrs: []string{"name"}}
+	tests := []struct {
+		term queryTerm
+		want bool
+	}{
+		{queryTerm{text: "gate"}, true},
+		{queryTerm{text: "Gate"}, true},
+		{queryTerm{text: "GATE"}, false},
+		{queryTerm{text: "api", match: matchPrefix}, true},
+		{queryTerm{text: "gateway", match: matchPrefix}, false},
+		{queryTerm{text: "way", match: matchSuffix}, true},
+		{queryTerm{text: "api gateway", match: matchExact}, true},
+		{queryTerm{text: "api", match: matchExact}, false},
+		{queryTerm{text: "gate", negate: true}, true},
+		{queryTerm{field: "name", text: "gate"}, true},
+		{queryTerm{field: "owner", text: ""}, false},
+		{queryTerm{field: "tags", text: "eu"}, true},
+		{queryTerm{field: "price", compare: ">", number: 100}, true},
+		{queryTerm{field: "price", compare: "<=", number: 100}, false},
+		{queryTerm{field: "price", compare: "=", number: 120}, true},
+		{queryTerm{field: "price", compare: "!=", number: 120}, false},
+		{queryTerm{field: "note", compare: ">", number: 0}, false},
+	}
+	for _, tt := range tests {
+		if got := app.matchTerm(tt.term, obj); got != tt.want {
+			t.Errorf("matchTerm(%+v) = %v, want %v", tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestHasAttr(t *testing.T) {
+	objects := []map[string]interface{}{
+		{"name": "api", "meta": map[string]interface{}{"zone": "eu"}, "tags": []interface{}{"a"}},
+		{elementKey: "plain"},
+	}
+	app := newApp(objects, []string{"name"}, "", nil, false, false, " - ")
+	evaluated := 0
+	app.columns = []computedColumn{{name: "total", eval: func(map[string]interface{}) (float64, bool) {
+		evaluated++
+		return 0, false
+	}}}
+
+	for attr, want := range map[string]bool{
+		"name": true, "meta": true, "meta.zone": true, "tags.0": true, "/meta/zone": true,
+		"value": true, ".name": true, "zone": false, "meta.region": false, "total": false,
+	} {
+		if got := app.hasAttr(attr); got != want {
+			t.Errorf("hasAttr(%s) = %v, want %v", attr, got, want)
+		}
+	}
+	app.hasAttr("total")
+	if evaluated != len(objects) {
+		t.Errorf("the column was evaluated %d times, want once per item", evaluated)
+	}
+
+	app.appendObjects([]map[string]interface{}{{"zone": "us"}}, nil)
+	if !app.hasAttr("zone") {
+		t.Error("hasAttr(zone) is false after an item with zone arrived")
+	}
+	app.replaceObjects([]map[string]interface{}{{"id": 1}}, nil)
+	if app.hasAttr("name") || !app.hasAttr("id") {
+		t.Error("hasAttr still sees the items before the reload")
+	}
+}
+
+func FuzzParseQuery(f *testing.F) {
+	for _, seed := range []string{
+		"prod api eu", `"api gateway"`, `!^"api gateway"$`, "name:api", "price>100",
+		"price>=", "has:name", "missing:tags", "(status:failed or status:error) and region:eu",
+		"not (a or", "((", "))", "f(x)", "'^", "!", "^$", `"`, "name:\"a", "é ÉCOLE",
+	} {
+		f.Add(seed)
+	}
+	app := &App{
+		objects: []map[string]interface{}{
+			{"name": "api gateway", "price": 120, "status": "failed", "region": "eu"},
+			{"name": "École", "tags": []interface{}{"a"}},
+			{elementKey: "plain"},
+		},
+		displayAttrs: []string{"name"},
+	}
+	f.Fuzz(func(t *testing.T, query string) {
+		if _, err := app.matchItems([]int{0, 1, 2}, query); err != nil {
+			t.Fatalf("matchItems(%q): %v", query, err)
+		}
+		app.filter = query
+		app.highlightPattern()
+		colored, _ := app.colorQuery(query)
+		if got := strings.NewReplacer(colorInfo, "", colorReset, "").Replace(colored); got != query {
+			t.Fatalf("colorQuery(%q) changes the text to %q", query, got)
+		}
+	})
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// screenBuffer is an in-memory terminal understanding the subset of escape
+// sequences qjp emits. Rendering into it instead of the tty gives frames
+// that can be inspected as plain text, e.g. to compare them against golden
+// files. Colors and hyperlinks are discarded. Each Write must contain
+// complete escape sequences, which holds for whole frames.
+type screenBuffer struct {
+	width, height int
+	cells         [][]string
+	row, col      int
+	saved         [2]int
+}
+
+func newScreenBuffer(width, height int) *screenBuffer {
+	s := &screenBuffer{width: width, height: height}
+	s.clear()
+	return s
+}
+
+func (s *screenBuffer) clear() {
+	s.cells = make([][]string, s.height)
+	for i := range s.cells {
+		s.cells[i] = s.blankRow()
+	}
+}
+
+func (s *screenBuffer) blankRow() []string {
+	row := make([]string, s.width)
+	for i := range row {
+		row[i] = " "
+	}
+	return row
+}
+
+func (s *screenBuffer) Write(p []byte) (int, error) {
+	text := string(p)
+	for text != "" {
+		switch text[0] {
+		case '\033':
+			text = s.escape(text)
+		case '\r':
+			s.col = 0
+			text = text[1:]
+		case '\n':
+			s.lineFeed()
+			text = text[1:]
+		default:
+			var cluster string
+			var width int
+			cluster, text, width, _ = uniseg.FirstGraphemeClusterInString(text, -1)
+			s.put(cluster, width)
+		}
+	}
+	return len(p), nil
+}
+
+func (s *screenBuffer) lineFeed() {
+	if s.row < s.height-1 {
+		s.row++
+		return
+	}
+	s.cells = append(s.cells[1:], s.blankRow())
+}
+
+// put writes a grapheme cluster at the cursor, wrapping at the right edge.
+// Wide clusters occupy their cell and an empty one after it.
+func (s *screenBuffer) put(cluster string, width int) {
+	if s.col+width > s.width {
+		s.col = 0
+		s.lineFeed()
+	}
+	if width == 0 || s.col >= s.width {
+		return
+	}
+	s.cells[s.row][s.col] = cluster
+	for i := 1; i < width; i++ {
+		s.cells[s.row][s.col+i] = ""
+	}
+	s.col += width
+}
+
+// escape consumes the escape sequence at the start of text and returns the
+// rest. Unknown sequences are skipped.
+func (s *screenBuffer) escape(text string) string {
+	if len(text) < 2 {
+		return ""
+	}
+
+	switch text[1] {
+	case ']':
+		// OSC, terminated by ST or BEL
+		if end := strings.Index(text, "\033\\"); end >= 0 {
+			return text[end+2:]
+		}
+		if end := strings.IndexByte(text, 7); end >= 0 {
+			return text[end+1:]
+		}
+		return ""
+	case '[':
+		end := 2
+		for end < len(text) && (text[end] < 0x40 || text[end] > 0x7e) {
+			end++
+		}
+		if end == len(text) {
+			return ""
+		}
+		s.csi(text[2:end], text[end])
+		return text[end+1:]
+	case '7':
+		s.saved = [2]int{s.row, s.col}
+	case '8':
+		s.row, s.col = s.saved[0], s.saved[1]
+	}
+	return text[2:]
+}
+
+func (s *screenBuffer) csi(params string, final byte) {
+	n := 1
+	if v, err := strconv.Atoi(params); err == nil && v > 0 {
+		n = v
+	}
+
+	switch final {
+	case 'J':
+		switch params {
+		case "2":
+			s.clear()
+		case "":
+			for row := s.row + 1; row < s.height; row++ {
+				s.cells[row] = s.blankRow()
+			}
+			s.clearRight()
+		}
+	case 'K':
+		switch params {
+		case "2":
+			s.cells[s.row] = s.blankRow()
+		case "":
+			s.clearRight()
+		}
+	case 'H':
+		s.row, s.col = 0, 0
+		if row, col, ok := strings.Cut(params, ";"); ok {
+			r, _ := strconv.Atoi(row)
+			c, _ := strconv.Atoi(col)
+			s.row = min(max(r-1, 0), s.height-1)
+			s.col = min(max(c-1, 0), s.width-1)
+		}
+	case 'A':
+		s.row = max(s.row-n, 0)
+	case 'B':
+		s.row = min(s.row+n, s.height-1)
+	case 'C':
+		s.col = min(s.col+n, s.width-1)
+	}
+}
+
+// clearRight blanks the cursor row from the cursor to the right edge.
+func (s *screenBuffer) clearRight() {
+	for col := s.col; col < s.width; col++ {
+		s.cells[s.row][col] = " "
+	}
+}
+
+// String returns the screen contents, one line per row, without trailing
+// blanks.
+func (s *screenBuffer) String() string {
+	lines := make([]string, len(s.cells))
+	for i, row := range s.cells {
+		lines[i] = strings.TrimRight(strings.Join(row, ""), " ")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n") + "\n"
+}
@@ -0,0 +1,239 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the frames rendered")
+
+// frameTest renders a dataset on a screen of a given size, after feeding
+// it a sequence of keys, and compares the frame with testdata/name.golden.
+type frameTest struct {
+	name          string
+	file          string
+	width, height int
+	setup         func(cfg *config)
+	keys          []string
+}
+
+var frameTests = []frameTest{
+	{name: "objects", file: "cars.json", width: 60, height: 10},
+	{
+		name: "display-attrs", file: "cars.json", width: 40, height: 8,
+		setup: func(cfg *config) { cfg.displayAttrs = []string{"make", "model"} },
+		keys:  []string{"\x1b[B", "\x1b[B"},
+	},
+	{
+		name: "filter", file: "languages.json", width: 40, height: 10,
+		setup: func(cfg *config) { cfg.displayAttrs = []string{"language"} },
+		keys:  []string{"a", "n", "\x1b[B", "\x7f"},
+	},
+	{
+		name: "query-operators", file: "cars.json", width: 50, height: 6,
+		setup: func(cfg *config) { cfg.displayAttrs = []string{"make", "price"} },
+		keys:  []string{"(", "!", "^", "T", " ", "p", "r", "i", "c", "e", ">", "x"},
+	},
+	{
+		name: "no-matches", file: "languages.json", width: 40, height: 6,
+		setup: func(cfg *config) { cfg.displayAttrs = []string{"language"} },
+		keys:  []string{"z", "z", "z"},
+	},
+	{
+		name: "table-sorted", file: "cars.json", width: 50, height: 9,
+		setup: func(cfg *config) {
+			cfg.displayAttrs = []string{"make", "price", "year"}
+			cfg.tableMode = true
+		},
+		keys: []string{"\x1bOR", "\x1bOR", "\x1bOS"},
+	},
+	{
+		name: "truncate-scrolled", file: "cars.json", width: 30, height: 6,
+		setup: func(cfg *config) { cfg.truncate = true },
+		keys:  []string{"\x1b[C"},
+	},
+	{
+		name: "multi-select", file: "languages.json", width: 30, height: 8,
+		setup: func(cfg *config) {
+			cfg.displayAttrs = []string{"iso_code", "language"}
+			cfg.multi = true
+		},
+		keys: []string{"\t", "\x1b[B", "\t"},
+	},
+	{
+		name: "preview", file: "cars-nested.json", width: 50, height: 16,
+		setup: func(cfg *config) { cfg.displayAttrs = []string{"make"} },
+		keys:  []string{"\x1f", "\x1b[B"},
+	},
+	{
+		name: "status-line", file: "languages.json", width: 50, height: 7,
+		setup: func(cfg *config) {
+			cfg.displayAttrs = []string{"language"}
+			cfg.info = "status"
+		},
+		keys: []string{"i", "\x1br"},
+	},
+	{
+		name: "drill", file: "cars-nested.json", width: 50, height: 10,
+		setup: func(cfg *config) { cfg.displayAttrs = []string{"make"} },
+		keys:  []string{"\t", "\x1b[F", "\t"},
+	},
+	{
+		name: "drill-ascend", file: "cars-nested.json", width: 50, height: 8,
+		setup: func(cfg *config) { cfg.displayAttrs = []string{"make"} },
+		keys:  []string{"\x1b[B", "\t", "\x1b[D"},
+	},
+	{
+		name: "group-by", file: "cars.json", width: 40, height: 16,
+		setup: func(cfg *config) {
+			cfg.displayAttrs = []string{"make"}
+			cfg.groupBy = "fuel_type"
+		},
+		keys: []string{"\x1b[1;3B", "\t"},
+	},
+	{
+		name: "facets", file: "cars.json", width: 80, height: 10,
+		setup: func(cfg *config) {
+			cfg.displayAttrs = []string{"make", "fuel_type"}
+			cfg.facet = "fuel_type"
+		},
+		keys: []string{"\x1bf", "\x1b[B", "\r"},
+	},
+	{
+		name: "value-popup", file: "cars.json", width: 60, height: 10,
+		setup: func(cfg *config) { cfg.displayAttrs = []string{"make", "fuel_type"} },
+		keys:  []string{"\x1bOQ", "\t", "\x1b[B"},
+	},
+	{
+		name: "value-popup-filter", file: "cars.json", width: 60, height: 10,
+		setup: func(cfg *config) { cfg.displayAttrs = []string{"make", "fuel_type"} },
+		keys:  []string{"\x1bOQ", "\t", "\x1b[B", "\r"},
+	},
+	{
+		name: "jq-mode", file: "cars.json", width: 60, height: 8,
+		setup: func(cfg *config) { cfg.displayAttrs = []string{"make"} },
+		keys:  append([]string{"\x1bj", "\x7f"}, strings.Split("select(.price > 40000) | .model", "")...),
+	},
+	{
+		name: "tree", file: "cars-nested.json", width: 50, height: 12,
+		setup: func(cfg *config) { cfg.tree = true },
+		keys:  []string{"\x1b[B", "\t", "\x1b[B", "\x1b[B", "\x1b[B", "\x1b[B", "\x1b[B", "\x1b[B", "\x1b[B", "\t"},
+	},
+}
+
+func TestFrames(t *testing.T) {
+	for _, tt := range frameTests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderFrame(t, tt)
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if got != string(want) {
+				t.Errorf("frame differs from %s:\n%s\nwant:\n%s", golden, got, want)
+			}
+		})
+	}
+}
+
+// renderFrame sets the picker up as main does for the options of tt, feeds
+// it the keys of tt and returns the last frame drawn.
+func renderFrame(t *testing.T, tt frameTest) string {
+	t.Helper()
+	input, err := os.ReadFile(filepath.Join("sample_json_files", tt.file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := parseArgs(nil, configFile{})
+	if tt.setup != nil {
+		tt.setup(&cfg)
+	}
+	objects, paths, err := loadItems(input, &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := newApp(objects, cfg.displayAttrs, "", nil, cfg.truncate, cfg.tableMode, cfg.separator)
+	app.paths = paths
+	app.info = cfg.info
+	app.multi = cfg.multi
+	if cfg.multi {
+		app.bindings["tab"] = action{name: "toggle"}
+	}
+	if cfg.groupBy != "" {
+		app.groupBy = cfg.groupBy
+		app.collapsed = make(map[string]bool)
+		app.updateFilter()
+	}
+	if cfg.facet != "" {
+		app.facetAttr = cfg.facet
+		app.openFacets(cfg.facet)
+	}
+	if cfg.tree {
+		app.treeMode = true
+		app.treeOpen = map[string]bool{".": true}
+		app.updateFilter()
+	}
+	screen := newScreenBuffer(tt.width, tt.height)
+	app.width, app.height, app.out = tt.width, tt.height, screen
+
+	app.render()
+	for _, key := range tt.keys {
+		if done, _ := app.handleInput([]byte(key)); done {
+			t.Fatalf("key %q ended the session", key)
+		}
+	}
+	return screen.String()
+}
+
+// TestKeepFrame checks that --keep-output draws the final frame below the
+// cursor, with the status line at the bottom of the frame, instead of at
+// the rows of the alternate screen. The frame is kept shorter than the
+// screen so that the lines above it can be seen.
+func TestKeepFrame(t *testing.T) {
+	objects := []map[string]interface{}{{"name": "alpha"}, {"name": "beta"}}
+	app := newApp(objects, []string{"name"}, "", nil, false, false, "")
+	app.info = "status"
+	screen := newScreenBuffer(20, 12)
+	app.width, app.height, app.out = 20, 8, screen
+
+	fmt.Fprint(screen, "$ ls\r\nfile\r\n$ qjp\r\n")
+	app.keepFrame()
+	want := strings.Join([]string{
+		"$ ls", "file", "$ qjp", "Filter:", "> alpha", "  beta", "", "", "", "", " 2/2  name",
+	}, "\n") + "\n"
+	if got := screen.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
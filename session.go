@@ -0,0 +1,171 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// A session file is a stream of JSON values: a sessionHeader followed by
+// one sessionEvent per chunk of keys read from the terminal.
+type sessionHeader struct {
+	Args   []string `json:"args"`
+	Width  int      `json:"width"`
+	Height int      `json:"height"`
+	Input  []byte   `json:"input"`
+}
+
+type sessionEvent struct {
+	Time int64  `json:"t"` // milliseconds since the session started
+	Keys []byte `json:"keys"`
+}
+
+type sessionRecorder struct {
+	path  string
+	file  *os.File
+	enc   *json.Encoder
+	start time.Time
+}
+
+func startRecording(path string, header sessionHeader) (*sessionRecorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &sessionRecorder{path: path, file: file, enc: json.NewEncoder(file), start: time.Now()}
+	if err := r.enc.Encode(header); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *sessionRecorder) recordKeys(keys []byte) {
+	_ = r.enc.Encode(sessionEvent{Time: time.Since(r.start).Milliseconds(), Keys: keys})
+}
+
+func (r *sessionRecorder) Close() error {
+	return r.file.Close()
+}
+
+// reopen appends to the recording again after Close.
+func (r *sessionRecorder) reopen() error {
+	file, err := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	r.file, r.enc = file, json.NewEncoder(file)
+	return nil
+}
+
+// closeSession closes the control socket and the recording, so that the
+// socket is removed and the recording is complete before qjp exits or is
+// replaced by another program. It can be called more than once.
+func (a *App) closeSession() {
+	if a.control != nil {
+		a.control.Close()
+		a.control = nil
+	}
+	if a.recorder != nil && a.recorder.file != nil {
+		a.recorder.Close()
+		a.recorder.file = nil
+	}
+}
+
+// reopenSession undoes closeSession when qjp goes on after all.
+func (a *App) reopenSession() error {
+	if a.recorder != nil && a.recorder.file == nil {
+		if err := a.recorder.reopen(); err != nil {
+			a.recorder = nil
+			return fmt.Errorf("recording session: %w", err)
+		}
+	}
+	if a.controlPath != "" && a.control == nil {
+		listener, err := listenControl(a.controlPath)
+		if err != nil {
+			return fmt.Errorf("opening control socket: %w", err)
+		}
+		a.control = listener
+		go serveControl(a.control, a.commands)
+	}
+	return nil
+}
+
+type session struct {
+	header sessionHeader
+	events []sessionEvent
+}
+
+func loadSession(path string) (*session, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	s := &session{}
+	dec := json.NewDecoder(file)
+	if err := dec.Decode(&s.header); err != nil {
+		return nil, fmt.Errorf("reading session %s: %w", path, err)
+	}
+	for {
+		var event sessionEvent
+		if err := dec.Decode(&event); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("reading session %s: %w", path, err)
+		}
+		s.events = append(s.events, event)
+	}
+	return s, nil
+}
+
+// replayKeys feeds recorded keys to the UI loop with their original timing.
+// If the recording ends without closing the picker, the tty takes over.
+func (a *App) replayKeys(events []sessionEvent, keys chan<- []byte, done <-chan struct{}, errs chan<- error) {
+	start := time.Now()
+	for _, event := range events {
+		time.Sleep(time.Until(start.Add(time.Duration(event.Time) * time.Millisecond)))
+		keys <- event.Keys
+		<-done
+	}
+	a.readKeys(keys, done, errs)
+}
+
+// withoutOption returns args with every occurrence of the given option and
+// its value removed.
+func withoutOption(args []string, option string) []string {
+	result := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		if args[i] == option {
+			i++
+			continue
+		}
+		result = append(result, args[i])
+	}
+	return result
+}
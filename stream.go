@@ -0,0 +1,253 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// streamBuffer is the number of decoded objects that can wait for the UI
+// before the decoder blocks.
+const streamBuffer = 1024
+
+// streamBatchInterval is how long the UI keeps taking objects from a stream
+// before drawing them, so that fast producers are not rendered per object.
+const streamBatchInterval = 50 * time.Millisecond
+
+// inputStream delivers the objects of an input as they are decoded, so the
+// picker can start before a slow producer has finished writing.
+type inputStream struct {
+	objects chan streamedObject
+	err     error // valid once objects is closed
+	read    int   // number of objects taken from the stream so far
+	entries bool  // whether the items are the keys of a single object
+}
+
+// streamedObject is a decoded object along with its text in the input,
+// which is nil in line mode.
+type streamedObject struct {
+	obj map[string]interface{}
+	raw json.RawMessage
+}
+
+// streamInput decodes r in the background: a JSON array, a stream of JSON
+// objects, or plain text lines in line mode. Other formats are only
+// delivered once the whole input has been read.
+func streamInput(r io.Reader, cfg config) *inputStream {
+	s := &inputStream{objects: make(chan streamedObject, streamBuffer)}
+	go func() {
+		defer close(s.objects)
+		if cfg.lineMode {
+			s.err = s.decodeLines(r)
+			return
+		}
+		br := bufio.NewReader(r)
+		if startsJSON(br) {
+			s.err = s.decodeJSON(br, cfg.ndjson)
+		} else {
+			s.err = s.decodeAll(br, cfg)
+		}
+	}()
+	return s
+}
+
+// startsJSON tells whether the input in r, which is left unread, starts
+// like JSON rather than in a format that has to be read in full to be
+// detected and parsed.
+func startsJSON(r *bufio.Reader) bool {
+	first, err := peekNonSpace(r)
+	if err != nil || (first != '[' && first != '{') {
+		return err != nil
+	}
+	// A TOML table header starts with [ as well
+	for n := 1; n <= 256; n++ {
+		b, err := r.Peek(n)
+		if err != nil || b[n-1] == '\n' {
+			return !isTOMLTable(string(bytes.TrimSpace(b)))
+		}
+	}
+	return true
+}
+
+// decodeAll reads the whole of r and delivers its objects at once.
+func (s *inputStream) decodeAll(r io.Reader, cfg config) error {
+	input, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	objects, raws, err := parseObjects(input, cfg)
+	if err != nil && !errors.Is(err, errNoObjects) {
+		return err
+	}
+	for i, obj := range objects {
+		s.objects <- streamedObject{obj, raws[i]}
+	}
+	return nil
+}
+
+func (s *inputStream) decodeLines(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		s.objects <- streamedObject{obj: map[string]interface{}{"line": scanner.Text()}}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading lines: %w", err)
+	}
+	return nil
+}
+
+// decodeJSON decodes a JSON array or a stream of JSON values. records tells
+// that a single object is a record, as with --ndjson, rather than listed by
+// its keys.
+func (s *inputStream) decodeJSON(r *bufio.Reader, records bool) error {
+	first, err := peekNonSpace(r)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(r)
+	switch first {
+	case '{':
+		// A single object is listed by its keys, so the first object is
+		// held back until it is known to be followed by others
+		var held *streamedObject
+		for n := 1; ; n++ {
+			var raw json.RawMessage
+			err := decoder.Decode(&raw)
+			if err == io.EOF {
+				if held != nil {
+					return s.sendEntries(held.raw)
+				}
+				return nil
+			}
+			var obj map[string]interface{}
+			if err == nil {
+				obj, err = decodeObject(raw)
+			}
+			if err != nil {
+				return fmt.Errorf("error parsing JSON lines: object %d: %w", n, err)
+			}
+			if n == 1 && !records {
+				held = &streamedObject{obj, raw}
+				continue
+			}
+			if held != nil {
+				s.objects <- *held
+				held = nil
+			}
+			s.objects <- streamedObject{obj, raw}
+		}
+	case '[':
+		if _, err := decoder.Token(); err != nil {
+			return fmt.Errorf("error parsing JSON: %w", err)
+		}
+		for n := 1; decoder.More(); n++ {
+			var raw json.RawMessage
+			err := decoder.Decode(&raw)
+			var obj map[string]interface{}
+			if err == nil {
+				obj, err = decodeElement(raw)
+			}
+			if err != nil {
+				return fmt.Errorf("error parsing JSON: object %d: %w", n, err)
+			}
+			s.objects <- streamedObject{obj, raw}
+		}
+		if _, err := decoder.Token(); err != nil {
+			return fmt.Errorf("error parsing JSON: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("error parsing JSON: expected an array or objects, found %q", first)
+	}
+}
+
+// sendEntries delivers the keys of a single input object as items.
+func (s *inputStream) sendEntries(raw json.RawMessage) error {
+	objects, raws, err := objectEntries(raw)
+	if err != nil {
+		return fmt.Errorf("error parsing JSON: %w", err)
+	}
+	s.entries = true
+	for i, obj := range objects {
+		s.objects <- streamedObject{obj, raws[i]}
+	}
+	return nil
+}
+
+// peekNonSpace skips leading whitespace in r and returns the next byte
+// without consuming it.
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			r.ReadByte()
+		default:
+			return b[0], nil
+		}
+	}
+}
+
+// next waits for the next object. It returns false at the end of the
+// input, when s.err tells whether it ended on an error.
+func (s *inputStream) next() (streamedObject, bool) {
+	item, ok := <-s.objects
+	if ok {
+		s.read++
+	}
+	return item, ok
+}
+
+// batch takes the objects that arrive within streamBatchInterval, starting
+// with first, along with their text in the input. It reports whether the
+// stream is still open.
+func (s *inputStream) batch(first streamedObject) ([]map[string]interface{}, []json.RawMessage, bool) {
+	objects := []map[string]interface{}{first.obj}
+	raws := []json.RawMessage{first.raw}
+	s.read++
+	timeout := time.After(streamBatchInterval)
+	for {
+		select {
+		case item, ok := <-s.objects:
+			if !ok {
+				return objects, raws, false
+			}
+			objects = append(objects, item.obj)
+			raws = append(raws, item.raw)
+			s.read++
+		case <-timeout:
+			return objects, raws, true
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// readAll takes every object of s until the end of the input.
+func readAll(t *testing.T, s *inputStream) []map[string]interface{} {
+	t.Helper()
+	var objects []map[string]interface{}
+	for {
+		item, ok := s.next()
+		if !ok {
+			break
+		}
+		objects = append(objects, item.obj)
+	}
+	if s.err != nil {
+		t.Fatal(s.err)
+	}
+	return objects
+}
+
+func TestStreamInputMixedArray(t *testing.T) {
+	for _, tt := range mixedArrays {
+		t.Run(tt.name, func(t *testing.T) {
+			s := streamInput(strings.NewReader(tt.input), config{})
+			checkMixedItems(t, readAll(t, s), tt.objects)
+		})
+	}
+}
+
+func TestStreamInputSingleLineNDJSON(t *testing.T) {
+	input := `{"id":1,"name":"a"}` + "\n"
+	s := streamInput(strings.NewReader(input), config{ndjson: true})
+	if objects := readAll(t, s); len(objects) != 1 || s.entries {
+		t.Errorf("got %d items, entries %v; want the record", len(objects), s.entries)
+	}
+	s = streamInput(strings.NewReader(input), config{})
+	if objects := readAll(t, s); len(objects) != 2 || !s.entries {
+		t.Errorf("got %d items, entries %v; want its keys", len(objects), s.entries)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// parseTOML decodes a TOML document. When its top level holds exactly one
+// array of tables, as with the [[package]] entries of a lock file, each of
+// those tables is an object; otherwise the whole document is one object.
+// Each object is converted to JSON text, keeping the order of its keys.
+func parseTOML(input []byte) ([]map[string]interface{}, []json.RawMessage, error) {
+	var doc map[string]interface{}
+	meta, err := toml.Decode(string(input), &doc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing TOML: %w", err)
+	}
+
+	// The decoded tables are Go maps, so recover the order of their keys
+	// from the order they were defined in
+	order := make(map[string][]string)
+	for _, key := range meta.Keys() {
+		parent := strings.Join(key[:len(key)-1], "\x00")
+		name := key[len(key)-1]
+		if !slices.Contains(order[parent], name) {
+			order[parent] = append(order[parent], name)
+		}
+	}
+
+	items := []interface{}{doc}
+	path := []string{}
+	var arrays []string
+	for name, value := range doc {
+		if _, ok := value.([]map[string]interface{}); ok {
+			arrays = append(arrays, name)
+		}
+	}
+	if len(arrays) == 1 {
+		items = nil
+		for _, table := range doc[arrays[0]].([]map[string]interface{}) {
+			items = append(items, table)
+		}
+		path = arrays
+	}
+
+	var objects []map[string]interface{}
+	var raws []json.RawMessage
+	for _, item := range items {
+		var buf bytes.Buffer
+		if err := writeTOMLAsJSON(&buf, item, path, order); err != nil {
+			return nil, nil, fmt.Errorf("error parsing TOML: %w", err)
+		}
+		obj, err := decodeObject(buf.Bytes())
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing TOML: object %d: %w", len(objects)+1, err)
+		}
+		objects = append(objects, obj)
+		raws = append(raws, buf.Bytes())
+	}
+	return objects, raws, nil
+}
+
+// writeTOMLAsJSON writes a decoded TOML value as JSON. Tables list their
+// keys in the order given by order for their path, and dates, times and
+// the float values JSON has no number for become strings.
+func writeTOMLAsJSON(buf *bytes.Buffer, value interface{}, path []string, order map[string][]string) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := slices.Clone(order[strings.Join(path, "\x00")])
+		keys = slices.DeleteFunc(keys, func(k string) bool {
+			_, ok := v[k]
+			return !ok
+		})
+		// Keys of tables inside inline arrays aren't listed
+		var rest []string
+		for k := range v {
+			if !slices.Contains(keys, k) {
+				rest = append(rest, k)
+			}
+		}
+		slices.Sort(rest)
+
+		buf.WriteByte('{')
+		for i, k := range append(keys, rest...) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			name, err := json.Marshal(k)
+			if err != nil {
+				return err
+			}
+			buf.Write(name)
+			buf.WriteByte(':')
+			if err := writeTOMLAsJSON(buf, v[k], append(path, k), order); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case []map[string]interface{}:
+		buf.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeTOMLAsJSON(buf, elem, path, order); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeTOMLAsJSON(buf, elem, path, order); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case time.Time:
+		// Local dates and times are decoded in zones named after their kind
+		switch v.Location().String() {
+		case "date-local":
+			value = v.Format(time.DateOnly)
+		case "time-local":
+			value = v.Format("15:04:05.999999999")
+		case "datetime-local":
+			value = v.Format("2006-01-02T15:04:05.999999999")
+		default:
+			value = v.Format(time.RFC3339Nano)
+		}
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			value = "nan"
+		case math.IsInf(v, 1):
+			value = "inf"
+		case math.IsInf(v, -1):
+			value = "-inf"
+		}
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
@@ -0,0 +1,355 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"strconv"
+	"strings"
+
+	"github.com/itchyny/gojq"
+)
+
+// treeItems returns the nodes of the JSON value in input as items for
+// --tree: the value itself, then every member of its objects and arrays in
+// input order. Each item holds the jq path, the key or array index, the
+// value and the depth of its node. Several JSON values are browsed as an
+// array of them, and input in another format as the array of its objects.
+func treeItems(input []byte, cfg config) ([]map[string]interface{}, []itemPath, error) {
+	if cfg.jq != "" {
+		// A single result is the value browsed, whatever its type
+		results, jqCfg, err := jqResults(input, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		var value interface{} = results
+		if len(results) == 1 {
+			value = results[0]
+		}
+		if input, err = gojq.Marshal(value); err != nil {
+			return nil, nil, fmt.Errorf("--jq: %w", err)
+		}
+		cfg = jqCfg
+	}
+	root, err := treeRoot(input, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var objects []map[string]interface{}
+	var paths []itemPath
+	var walk func(raw json.RawMessage, path string, key interface{}, depth int) error
+	walk = func(raw json.RawMessage, path string, key interface{}, depth int) error {
+		members, keys, err := treeMembers(raw)
+		if err != nil {
+			return err
+		}
+		// Objects and arrays keep their text, which is part of the input,
+		// rather than a decoded copy at every level above their members
+		var value interface{} = treeValue{raw: raw, members: len(members)}
+		if raw[0] != '{' && raw[0] != '[' {
+			if value, err = decodeJQValue(raw); err != nil {
+				return err
+			}
+		}
+		objects = append(objects, map[string]interface{}{
+			"path":  path,
+			"key":   key,
+			"value": value,
+			"depth": json.Number(strconv.Itoa(depth)),
+		})
+		paths = append(paths, itemPath{object: path, raw: raw, value: true})
+
+		for i, member := range members {
+			var child string
+			switch k := keys[i].(type) {
+			case string:
+				child = jqKey(k)
+			default:
+				child = fmt.Sprintf("[%v]", k)
+			}
+			if path != "." {
+				child = path + child
+			} else if child[0] == '[' {
+				child = "." + child
+			}
+			if err := walk(member, child, keys[i], depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(root, ".", nil, 0); err != nil {
+		return nil, nil, fmt.Errorf("error parsing JSON: %w", err)
+	}
+	return objects, paths, nil
+}
+
+// treeRoot returns the JSON text of the value browsed with --tree.
+func treeRoot(input []byte, cfg config) (json.RawMessage, error) {
+	if err := detectFormat(&cfg, input); err != nil {
+		return nil, err
+	}
+	var values []json.RawMessage
+	if cfg.yaml || cfg.toml || cfg.csv || cfg.tsv || cfg.lineMode {
+		_, raws, err := parseObjects(input, cfg)
+		if err != nil && !errors.Is(err, errNoObjects) {
+			return nil, err
+		}
+		values = raws
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(input))
+		for {
+			var raw json.RawMessage
+			err := decoder.Decode(&raw)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error parsing JSON: %w", err)
+			}
+			values = append(values, raw)
+		}
+		if len(values) == 0 {
+			return nil, errNoObjects
+		}
+		if len(values) == 1 {
+			return values[0], nil
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, raw := range values {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(raw)
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+// treeValue is the value of an object or array node in --tree mode: its
+// JSON text, which object decodes when the node is output, and the number
+// of its members.
+type treeValue struct {
+	raw     json.RawMessage
+	members int
+}
+
+func (v treeValue) MarshalJSON() ([]byte, error) {
+	return v.raw, nil
+}
+
+func (v treeValue) String() string {
+	return string(v.raw)
+}
+
+// treeMembers returns the values of the members of the JSON object or
+// array raw in input order, along with their keys or indices. Other values
+// have no members. The values are parts of raw rather than copies.
+func treeMembers(raw json.RawMessage) ([]json.RawMessage, []interface{}, error) {
+	var members []json.RawMessage
+	var keys []interface{}
+	if raw[0] != '{' && raw[0] != '[' {
+		return nil, nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := decoder.Token(); err != nil {
+		return nil, nil, err
+	}
+	for decoder.More() {
+		if raw[0] == '{' {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, nil, err
+			}
+			keys = append(keys, token.(string))
+		} else {
+			keys = append(keys, json.Number(strconv.Itoa(len(members))))
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		end := decoder.InputOffset()
+		members = append(members, raw[end-int64(len(value)):end])
+	}
+	return members, keys, nil
+}
+
+// treeDepth returns the depth of the node held by obj, 0 for the root.
+func treeDepth(obj map[string]interface{}) int {
+	n, _ := obj["depth"].(json.Number)
+	depth, _ := strconv.Atoi(string(n))
+	return depth
+}
+
+// treeContainer tells whether the node held by obj is an object or array
+// that has members, which can be expanded.
+func treeContainer(obj map[string]interface{}) bool {
+	v, ok := obj["value"].(treeValue)
+	return ok && v.members > 0
+}
+
+// treeNode returns the node held by obj with the value of an object or
+// array decoded, for output.
+func treeNode(obj map[string]interface{}) map[string]interface{} {
+	v, ok := obj["value"].(treeValue)
+	if !ok {
+		return obj
+	}
+	value, err := decodeJQValue(v.raw)
+	if err != nil {
+		return obj
+	}
+	node := maps.Clone(obj)
+	node["value"] = value
+	return node
+}
+
+// visibleNodes returns the candidates whose ancestors are all expanded, as
+// listed in --tree mode without a filter.
+func (a *App) visibleNodes(candidates []int) []int {
+	visible := []int{}
+	hiddenBelow := -1
+	for _, i := range candidates {
+		obj := a.objects[i]
+		depth := treeDepth(obj)
+		if hiddenBelow >= 0 && depth > hiddenBelow {
+			continue
+		}
+		hiddenBelow = -1
+		visible = append(visible, i)
+		if treeContainer(obj) && !a.treeOpen[a.paths[i].object] {
+			hiddenBelow = depth
+		}
+	}
+	return visible
+}
+
+// treeRow returns the row shown for a node in --tree mode: the node
+// indented by its depth under its expanded parents, or its full path once
+// the list is filtered, followed by its value. Objects and arrays show the
+// number of their members instead.
+func (a *App) treeRow(obj map[string]interface{}) string {
+	path, _ := obj["path"].(string)
+
+	var preview string
+	switch v := obj["value"].(type) {
+	case treeValue:
+		if v.raw[0] == '{' {
+			preview = "{…} " + plural(v.members, "key")
+		} else {
+			preview = "[…] " + plural(v.members, "item")
+		}
+	default:
+		data, _ := json.Marshal(v)
+		preview = string(data)
+	}
+	if a.treeFiltered() {
+		return path + ": " + preview
+	}
+
+	marker := "  "
+	if treeContainer(obj) {
+		marker = "▸ "
+		if a.treeOpen[path] {
+			marker = "▾ "
+		}
+	}
+	label := path
+	if key, ok := obj["key"]; ok && key != nil {
+		label = fmt.Sprint(key)
+	}
+	return strings.Repeat("  ", treeDepth(obj)) + marker + label + ": " + preview
+}
+
+// treeFiltered tells whether every node is listed in --tree mode, rather
+// than those under expanded nodes.
+func (a *App) treeFiltered() bool {
+	return a.filter != "" || len(a.filterStack) > 0
+}
+
+// expandNode expands the highlighted node in --tree mode. It reports
+// false when the node is not a collapsed object or array.
+func (a *App) expandNode() bool {
+	if !a.treeMode || a.cursor >= len(a.filtered) {
+		return false
+	}
+	idx := a.filtered[a.cursor]
+	path := a.paths[idx].object
+	if !treeContainer(a.objects[idx]) || a.treeOpen[path] {
+		return false
+	}
+	a.treeOpen[path] = true
+	a.resort()
+	return true
+}
+
+// collapseNode collapses the highlighted node in --tree mode, or moves the
+// cursor to its parent when it is not expanded. It reports false at the
+// top of the tree.
+func (a *App) collapseNode() bool {
+	if !a.treeMode || a.cursor >= len(a.filtered) {
+		return false
+	}
+	idx := a.filtered[a.cursor]
+	path := a.paths[idx].object
+	if treeContainer(a.objects[idx]) && a.treeOpen[path] {
+		delete(a.treeOpen, path)
+		a.resort()
+		return true
+	}
+	depth := treeDepth(a.objects[idx])
+	for i := a.cursor - 1; i >= 0; i-- {
+		if treeDepth(a.objects[a.filtered[i]]) < depth {
+			a.cursor = i
+			return true
+		}
+	}
+	return false
+}
+
+// toggleNode expands or collapses the highlighted node in --tree mode.
+func (a *App) toggleNode() {
+	if !a.expandNode() && a.cursor < len(a.filtered) {
+		path := a.paths[a.filtered[a.cursor]].object
+		if a.treeOpen[path] {
+			delete(a.treeOpen, path)
+			a.resort()
+		}
+	}
+}
+
+// plural returns n followed by noun, in the plural unless n is 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
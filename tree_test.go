@@ -0,0 +1,154 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"slices"
+	"testing"
+	"unsafe"
+)
+
+const treeInput = `{"name": "api", "spec": {"ports": [80, 443], "tls": {}}, "tags": ["a"]}`
+
+// newTreeApp browses treeInput as --tree does, with the root expanded.
+func newTreeApp(t *testing.T) *App {
+	t.Helper()
+	objects, paths, err := treeItems([]byte(treeInput), config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := newApp(objects, nil, "", nil, false, false, "")
+	app.paths = paths
+	app.treeMode = true
+	app.treeOpen = map[string]bool{".": true}
+	app.updateFilter()
+	return app
+}
+
+// listedPaths returns the paths of the listed nodes.
+func listedPaths(app *App) []string {
+	var paths []string
+	for _, idx := range app.filtered {
+		paths = append(paths, app.paths[idx].object)
+	}
+	return paths
+}
+
+func TestTreeItems(t *testing.T) {
+	objects, paths, err := treeItems([]byte(treeInput), config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{".", ".name", ".spec", ".spec.ports", ".spec.ports[0]", ".spec.ports[1]", ".spec.tls", ".tags", ".tags[0]"}
+	var got []string
+	for _, obj := range objects {
+		got = append(got, obj["path"].(string))
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("paths = %q, want %q", got, want)
+	}
+
+	// The text of every node is part of the root's, not a copy of it
+	root := paths[0].raw
+	start, end := uintptr(unsafe.Pointer(&root[0])), uintptr(unsafe.Pointer(&root[len(root)-1]))
+	for i, path := range paths {
+		if p := uintptr(unsafe.Pointer(&path.raw[0])); p < start || p > end {
+			t.Errorf("node %s holds a copy of its text", want[i])
+		}
+	}
+	if v, ok := objects[2]["value"].(treeValue); !ok || v.members != 2 || string(v.raw) != `{"ports": [80, 443], "tls": {}}` {
+		t.Errorf(".spec holds %#v", objects[2]["value"])
+	}
+	if objects[1]["value"] != "api" {
+		t.Errorf(".name holds %#v, want api", objects[1]["value"])
+	}
+}
+
+func TestTreeExpandCollapse(t *testing.T) {
+	app := newTreeApp(t)
+	if got, want := listedPaths(app), []string{".", ".name", ".spec", ".tags"}; !slices.Equal(got, want) {
+		t.Fatalf("listed %q at first, want %q", got, want)
+	}
+
+	app.cursor = 2 // .spec
+	if !app.expandNode() {
+		t.Fatal("expandNode() = false on .spec")
+	}
+	if got, want := listedPaths(app), []string{".", ".name", ".spec", ".spec.ports", ".spec.tls", ".tags"}; !slices.Equal(got, want) {
+		t.Errorf("listed %q after expanding .spec, want %q", got, want)
+	}
+	if app.expandNode() {
+		t.Error("expandNode() = true on an expanded node")
+	}
+
+	app.cursor = 4 // .spec.tls, an empty object
+	if app.expandNode() {
+		t.Error("expandNode() = true on an empty object")
+	}
+	if !app.collapseNode() || app.cursor != 2 {
+		t.Errorf("collapseNode() on .spec.tls left the cursor on %d, want its parent", app.cursor)
+	}
+	if !app.collapseNode() {
+		t.Fatal("collapseNode() = false on the expanded .spec")
+	}
+	if got, want := listedPaths(app), []string{".", ".name", ".spec", ".tags"}; !slices.Equal(got, want) {
+		t.Errorf("listed %q after collapsing .spec, want %q", got, want)
+	}
+
+	app.cursor = 0
+	app.toggleNode()
+	if got := listedPaths(app); !slices.Equal(got, []string{"."}) {
+		t.Errorf("listed %q after collapsing the root, want only it", got)
+	}
+	if app.collapseNode() {
+		t.Error("collapseNode() = true at the top of the tree")
+	}
+}
+
+func TestTreeFilterListsCollapsedNodes(t *testing.T) {
+	app := newTreeApp(t)
+	app.filter = "443"
+	app.updateFilter()
+	if got, want := listedPaths(app), []string{".spec.ports[1]"}; !slices.Equal(got, want) {
+		t.Fatalf("listed %q for 443, want %q", got, want)
+	}
+	if got, want := app.getDisplayValue(app.objects[app.filtered[0]]), ".spec.ports[1]: 443"; got != want {
+		t.Errorf("row %q, want %q", got, want)
+	}
+}
+
+func TestTreeNodeOutput(t *testing.T) {
+	app := newTreeApp(t)
+	if got, want := app.getDisplayValue(app.objects[2]), "  ▸ spec: {…} 2 keys"; got != want {
+		t.Errorf("row %q, want %q", got, want)
+	}
+	val, ok := app.object(2)["value"].(map[string]interface{})
+	if !ok || len(val) != 2 {
+		t.Fatalf("object(.spec) value = %#v, want the decoded object", app.object(2)["value"])
+	}
+	if _, ok := app.objects[2]["value"].(treeValue); !ok {
+		t.Error("object() decoded the value in place")
+	}
+	got, err := formatOutputValue(app.object(7)["value"])
+	if err != nil || got != `["a"]` {
+		t.Errorf("output of .tags = %s, %v", got, err)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/itchyny/gojq"
+)
+
+// uniqueFilter drops the items of --unique that are duplicates of an item
+// seen before, in the initial input, in later streamed objects or after a
+// reload.
+type uniqueFilter struct {
+	attr    string     // attribute compared, or the whole object when empty
+	code    *gojq.Code // compiled attr when it is a jq expression
+	seen    map[string]bool
+	removed int // number of duplicates dropped so far
+}
+
+func newUniqueFilter(attr string) *uniqueFilter {
+	u := &uniqueFilter{attr: attr, seen: make(map[string]bool)}
+	if isJQExpr(attr) {
+		u.code, _ = compileJQ(attr)
+	}
+	return u
+}
+
+// key returns the text items are compared by. It reports false for items
+// without the attribute, which are always kept.
+func (u *uniqueFilter) key(obj map[string]interface{}) (string, bool) {
+	if u.attr == "" {
+		// Maps are written with sorted keys, so key order doesn't matter
+		data, err := json.Marshal(obj)
+		return string(data), err == nil
+	}
+
+	var val interface{}
+	var ok bool
+	if u.code != nil {
+		var input interface{} = obj
+		if v, isElement := elementValue(obj); isElement {
+			input = v
+		}
+		val, ok = u.code.Run(input).Next()
+		if _, failed := val.(error); failed {
+			ok = false
+		}
+	} else if !isJQExpr(u.attr) {
+		val, ok = lookupAttr(obj, u.attr)
+	}
+	if !ok {
+		return "", false
+	}
+	data, err := json.Marshal(jqNumber(val))
+	return string(data), err == nil
+}
+
+// apply returns the objects, along with their paths, that are not
+// duplicates of an object already seen.
+func (u *uniqueFilter) apply(objects []map[string]interface{}, paths []itemPath) ([]map[string]interface{}, []itemPath) {
+	var kept []map[string]interface{}
+	var keptPaths []itemPath
+	for i, obj := range objects {
+		if key, ok := u.key(obj); ok {
+			if u.seen[key] {
+				u.removed++
+				continue
+			}
+			u.seen[key] = true
+		}
+		kept = append(kept, obj)
+		if i < len(paths) {
+			keptPaths = append(keptPaths, paths[i])
+		}
+	}
+	return kept, keptPaths
+}
+
+// reset forgets the objects seen, before the input is read again.
+func (u *uniqueFilter) reset() {
+	u.seen = make(map[string]bool)
+	u.removed = 0
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestUniqueFilter(t *testing.T) {
+	objects := []map[string]interface{}{
+		{"id": json.Number("1"), "name": "a", "meta": map[string]interface{}{"zone": "eu"}},
+		{"name": "a", "id": json.Number("1"), "meta": map[string]interface{}{"zone": "eu"}},
+		{"id": json.Number("2"), "name": "a", "meta": map[string]interface{}{"zone": "us"}},
+		{"id": json.Number("1"), "name": "b"},
+		{"name": "c"},
+		{"name": "c"},
+		{elementKey: "a"},
+	}
+	tests := []struct {
+		attr    string
+		kept    []string // paths of the kept items
+		removed int
+	}{
+		{"", []string{".[0]", ".[2]", ".[3]", ".[4]", ".[6]"}, 2},
+		{"id", []string{".[0]", ".[2]", ".[4]", ".[5]", ".[6]"}, 2},
+		{"name", []string{".[0]", ".[3]", ".[4]", ".[6]"}, 3},
+		{"meta.zone", []string{".[0]", ".[2]", ".[3]", ".[4]", ".[5]", ".[6]"}, 1},
+		{".name | ascii_upcase", []string{".[0]", ".[3]", ".[4]", ".[6]"}, 3},
+		{"value", []string{".[0]", ".[1]", ".[2]", ".[3]", ".[4]", ".[5]", ".[6]"}, 0},
+	}
+	for _, tt := range tests {
+		paths := make([]itemPath, len(objects))
+		for i := range paths {
+			paths[i] = itemPath{object: fmt.Sprintf(".[%d]", i)}
+		}
+		u := newUniqueFilter(tt.attr)
+		kept, keptPaths := u.apply(objects, paths)
+		var got []string
+		for _, p := range keptPaths {
+			got = append(got, p.String())
+		}
+		if len(kept) != len(keptPaths) || !slices.Equal(got, tt.kept) || u.removed != tt.removed {
+			t.Errorf("--unique=%s kept %v (%d objects), removed %d; want %v, %d", tt.attr, got, len(kept), u.removed, tt.kept, tt.removed)
+		}
+	}
+}
+
+func TestUniqueFilterAcrossBatches(t *testing.T) {
+	u := newUniqueFilter("id")
+	first, _ := u.apply([]map[string]interface{}{{"id": "a"}, {"id": "b"}}, nil)
+	later, _ := u.apply([]map[string]interface{}{{"id": "b"}, {"id": "c"}}, nil)
+	if len(first) != 2 || len(later) != 1 || later[0]["id"] != "c" || u.removed != 1 {
+		t.Errorf("streamed batches kept %v then %v, removed %d", first, later, u.removed)
+	}
+
+	u.reset()
+	again, _ := u.apply([]map[string]interface{}{{"id": "a"}, {"id": "a"}}, nil)
+	if len(again) != 1 || u.removed != 1 {
+		t.Errorf("after reset kept %v, removed %d; want 1 and 1", again, u.removed)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// displayWidth returns the number of terminal cells s occupies. Grapheme
+// clusters such as emoji ZWJ sequences, flags and letters with combining
+// marks count as a single character.
+func displayWidth(s string) int {
+	return uniseg.StringWidth(s)
+}
+
+// truncateWidth shortens s to at most width cells, replacing the cut tail
+// with "...". Grapheme clusters are never split.
+func truncateWidth(s string, width int) string {
+	if displayWidth(s) <= width {
+		return s
+	}
+
+	limit := width - 3
+	used, end := 0, 0
+	state := -1
+	rest := s
+	for rest != "" {
+		var cluster string
+		var w int
+		cluster, rest, w, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		if used+w > limit {
+			break
+		}
+		used += w
+		end += len(cluster)
+	}
+	return s[:end] + "..."
+}
+
+// padRight pads s with spaces to width cells.
+func padRight(s string, width int) string {
+	return s + strings.Repeat(" ", max(0, width-displayWidth(s)))
+}
+
+// skipWidth drops the first n cells of s. A wide grapheme cluster straddling
+// the boundary is dropped as a whole.
+func skipWidth(s string, n int) string {
+	skipped := 0
+	state := -1
+	rest := s
+	for rest != "" && skipped < n {
+		var w int
+		_, rest, w, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		skipped += w
+	}
+	return rest
+}
+
+// trimLastGrapheme removes the last grapheme cluster from s.
+func trimLastGrapheme(s string) string {
+	end := 0
+	state := -1
+	rest := s
+	for rest != "" {
+		var cluster string
+		cluster, rest, _, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		if rest == "" {
+			break
+		}
+		end += len(cluster)
+	}
+	return s[:end]
+}
@@ -0,0 +1,196 @@
+// Copyright (c) 2025 Pedro (http://github.com/plainas)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseYAML decodes a YAML document, or a stream of documents separated by
+// ---. A document holding a list contributes its elements as objects, any
+// other document is an object itself. Each object is converted to JSON
+// text, keeping the order of its keys.
+func parseYAML(input []byte) ([]map[string]interface{}, []json.RawMessage, error) {
+	var objects []map[string]interface{}
+	var raws []json.RawMessage
+
+	decoder := yaml.NewDecoder(bytes.NewReader(input))
+	for doc := 1; ; doc++ {
+		var node yaml.Node
+		err := decoder.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			return objects, raws, nil
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing YAML: %w", err)
+		}
+
+		root := &node
+		if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+			root = root.Content[0]
+		}
+		items := []*yaml.Node{root}
+		if root.Kind == yaml.SequenceNode {
+			items = root.Content
+		}
+
+		for _, item := range items {
+			var buf bytes.Buffer
+			if err := writeYAMLAsJSON(&buf, item, map[*yaml.Node]bool{}); err != nil {
+				return nil, nil, fmt.Errorf("error parsing YAML: document %d: %w", doc, err)
+			}
+			obj, err := decodeObject(buf.Bytes())
+			if err != nil {
+				return nil, nil, fmt.Errorf("error parsing YAML: document %d: object %d: %w", doc, len(objects)+1, err)
+			}
+			objects = append(objects, obj)
+			raws = append(raws, buf.Bytes())
+		}
+	}
+}
+
+// writeYAMLAsJSON writes the value of node as JSON. Scalars are converted
+// according to their resolved tag, so that `1`, `true` and `null` keep
+// their type, and values JSON has no type for (timestamps, binary data,
+// infinities) become strings. Aliases are expanded and merge keys (<<)
+// are inlined. open holds the collections being written, so that an alias
+// to one of them, which has no JSON form, is an error instead of an
+// endless recursion.
+func writeYAMLAsJSON(buf *bytes.Buffer, node *yaml.Node, open map[*yaml.Node]bool) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeYAMLAsJSON(buf, node.Content[0], open)
+	case yaml.AliasNode:
+		return writeYAMLAsJSON(buf, node.Alias, open)
+	case yaml.SequenceNode:
+		if open[node] {
+			return fmt.Errorf("line %d: recursive alias", node.Line)
+		}
+		open[node] = true
+		defer delete(open, node)
+		buf.WriteByte('[')
+		for i, elem := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeYAMLAsJSON(buf, elem, open); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		first := true
+		if err := writeYAMLPairs(buf, node, &first, open); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+		return nil
+	}
+
+	var value interface{}
+	switch node.ShortTag() {
+	case "!!null":
+		value = nil
+	case "!!bool", "!!int", "!!float":
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		if f, ok := value.(float64); ok && (math.IsInf(f, 0) || math.IsNaN(f)) {
+			value = node.Value
+		}
+	default:
+		value = node.Value
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
+
+// writeYAMLPairs writes the key-value pairs of a mapping as JSON members.
+// first tells whether a member was written before, for the commas.
+func writeYAMLPairs(buf *bytes.Buffer, node *yaml.Node, first *bool, open map[*yaml.Node]bool) error {
+	if open[node] {
+		return fmt.Errorf("line %d: recursive alias", node.Line)
+	}
+	open[node] = true
+	defer delete(open, node)
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.ShortTag() == "!!merge" {
+			if err := writeYAMLMerge(buf, value, first, open); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !*first {
+			buf.WriteByte(',')
+		}
+		*first = false
+		name, err := json.Marshal(key.Value)
+		if err != nil {
+			return err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		if err := writeYAMLAsJSON(buf, value, open); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeYAMLMerge inlines the mappings referred to by a merge key: a mapping
+// or a list of mappings.
+func writeYAMLMerge(buf *bytes.Buffer, node *yaml.Node, first *bool, open map[*yaml.Node]bool) error {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		return writeYAMLPairs(buf, node, first, open)
+	case yaml.SequenceNode:
+		for _, elem := range node.Content {
+			if err := writeYAMLMerge(buf, elem, first, open); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("line %d: merge key needs a mapping", node.Line)
+}